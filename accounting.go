@@ -0,0 +1,129 @@
+// in agora/accounting.go
+
+package agora
+
+import (
+	"sort"
+	"strings"
+)
+
+// ModelPrice is what a model costs per million tokens.
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// PriceTable maps model names to prices. A model without an exact entry uses
+// the longest entry that prefixes its name, so "gpt-4o" also prices
+// "gpt-4o-2024-08-06".
+type PriceTable map[string]ModelPrice
+
+// Price returns the price of a model.
+func (p PriceTable) Price(model string) (ModelPrice, bool) {
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+	best, found := "", false
+	for name := range p {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best, found = name, true
+		}
+	}
+	return p[best], found
+}
+
+// Cost prices the usage of a single call. The boolean is false when the
+// model has no price.
+func (p PriceTable) Cost(model string, usage Usage) (float64, bool) {
+	price, ok := p.Price(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(usage.PromptTokens)*price.InputPerMillion + float64(usage.CompletionTokens)*price.OutputPerMillion) / 1e6, true
+}
+
+// UsageTotals aggregates LLM calls.
+type UsageTotals struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+// Add returns the sum of two totals.
+func (t UsageTotals) Add(other UsageTotals) UsageTotals {
+	return UsageTotals{
+		Calls:            t.Calls + other.Calls,
+		PromptTokens:     t.PromptTokens + other.PromptTokens,
+		CompletionTokens: t.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      t.TotalTokens + other.TotalTokens,
+		Cost:             t.Cost + other.Cost,
+	}
+}
+
+// UsageEntry is the usage of one model by one node.
+type UsageEntry struct {
+	Node  string `json:"node"` // Namespaced node path
+	Model string `json:"model"`
+	UsageTotals
+}
+
+// UsageReport breaks down the LLM usage of an execution.
+type UsageReport struct {
+	Total   UsageTotals            `json:"total"`
+	ByNode  map[string]UsageTotals `json:"by_node"`
+	ByModel map[string]UsageTotals `json:"by_model"`
+	// Entries is the finest breakdown, by node and model, sorted.
+	Entries []UsageEntry `json:"entries"`
+	// Unpriced lists models that had no price, so their cost counts as zero.
+	Unpriced []string `json:"unpriced,omitempty"`
+}
+
+// UsageReport aggregates every LLM call of the trace, including sub-graphs,
+// pricing them with prices. A nil price table reports tokens only.
+func (t *Trace) UsageReport(prices PriceTable) UsageReport {
+	report := UsageReport{ByNode: map[string]UsageTotals{}, ByModel: map[string]UsageTotals{}}
+	entries := map[[2]string]UsageTotals{}
+	unpriced := map[string]bool{}
+
+	var walk func(t *Trace)
+	walk = func(t *Trace) {
+		for _, step := range t.Steps {
+			for _, call := range step.LLMCalls {
+				cost, ok := prices.Cost(call.Model, call.Usage)
+				if !ok && prices != nil {
+					unpriced[call.Model] = true
+				}
+				totals := UsageTotals{
+					Calls:            1,
+					PromptTokens:     call.Usage.PromptTokens,
+					CompletionTokens: call.Usage.CompletionTokens,
+					TotalTokens:      call.Usage.TotalTokens,
+					Cost:             cost,
+				}
+				report.Total = report.Total.Add(totals)
+				report.ByNode[step.Path] = report.ByNode[step.Path].Add(totals)
+				report.ByModel[call.Model] = report.ByModel[call.Model].Add(totals)
+				key := [2]string{step.Path, call.Model}
+				entries[key] = entries[key].Add(totals)
+			}
+			for _, sub := range step.SubTraces {
+				walk(sub)
+			}
+		}
+	}
+	walk(t)
+
+	for key, totals := range entries {
+		report.Entries = append(report.Entries, UsageEntry{Node: key[0], Model: key[1], UsageTotals: totals})
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		if report.Entries[i].Node != report.Entries[j].Node {
+			return report.Entries[i].Node < report.Entries[j].Node
+		}
+		return report.Entries[i].Model < report.Entries[j].Model
+	})
+	report.Unpriced = sortedKeys(unpriced)
+	return report
+}
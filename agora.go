@@ -10,11 +10,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
-// ErrMaxStepsExceeded is returned when the graph execution exceeds the defined MaxSteps.
+// ErrMaxStepsExceeded matches the *MaxStepsError returned when the graph
+// execution exceeds the defined MaxSteps.
 var ErrMaxStepsExceeded = errors.New("execution exceeded max steps")
 
+// END is the magic node name that terminates execution. Adding an edge to END
+// marks a node as terminal.
+const END = "END"
+
 // ToolCall represents the LLM's request to call a specific tool.
 type ToolCall struct {
 	ID       string `json:"id"`
@@ -25,13 +31,15 @@ type ToolCall struct {
 	} `json:"function"`
 }
 
-// ChatMessage defines the universal format for conversational turns
+// ChatMessage defines the universal format for conversational turns.
+// Multimodal messages carry their content in Parts; see content.go.
 type ChatMessage struct {
-	Content          string     `json:"content"`
-	ReasoningContent string     `json:"reasoning_content"`
-	Role             string     `json:"role"`
-	ToolCalls        []ToolCall `json:"tool_calls,omitempty"`
-	ToolCallID       string     `json:"tool_call_id,omitempty"`
+	Content          string        `json:"content"`
+	Parts            []ContentPart `json:"-"`
+	ReasoningContent string        `json:"reasoning_content"`
+	Role             string        `json:"role"`
+	ToolCalls        []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID       string        `json:"tool_call_id,omitempty"`
 }
 
 type Choice struct {
@@ -64,6 +72,13 @@ type ModelRequest struct {
 	Stream     bool             `json:"stream,omitempty"`
 	Tools      []ToolDefinition `json:"tools,omitempty"`
 	ToolChoice string           `json:"tool_choice,omitempty"`
+
+	// Generation parameters. Nil or zero values leave the provider's default.
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
 }
 
 type ModelResponse struct {
@@ -75,6 +90,9 @@ type ModelResponse struct {
 	SystemFingerprint string   `json:"system_fingerprint"`
 	Timings           Timings  `json:"timings"`
 	Usage             Usage    `json:"usage"`
+	// Backend names the backend that served the response when the model is
+	// a composite such as llm.FallbackLLM. It is never sent by providers.
+	Backend string `json:"backend,omitempty"`
 }
 
 // NodeResult is what a node returns after it executes. It contains the
@@ -96,8 +114,18 @@ type Graph struct {
 	Nodes            map[string]NodeFunc
 	Edges            map[string]string
 	ConditionalEdges map[string]func(s State) string
-	Entry            string
-	MaxSteps         int // Circuit breaker defaults to 25
+	// ConditionalTargets declares the possible destinations of each conditional
+	// edge so validation and visualization can see them.
+	ConditionalTargets map[string][]string
+	Entry              string
+	MaxSteps           int                   // Circuit breaker defaults to 25
+	NodeConfigs        map[string]NodeConfig // Per-node retry, timeout and fallback policies
+	Middleware         []Middleware          // Applied to every node, see Use
+	Checkpointer       Checkpointer          // Saves the state after every step of runs tagged WithRunID
+	Budget             Budget                // Token, cost, time and call limits, shared with nested graphs
+	// InheritMiddleware also applies Middleware to the nodes of every graph
+	// executed inside this one, such as a SubGraphNode.
+	InheritMiddleware bool
 }
 
 // ToolDefinition defines the structure for a tool that can be used by agents.
@@ -116,17 +144,31 @@ type Function struct {
 // A constructor to get a graph
 func NewGraph() *Graph {
 	return &Graph{
-		Nodes:            make(map[string]NodeFunc),
-		Edges:            make(map[string]string),
-		ConditionalEdges: make(map[string]func(s State) string),
-		MaxSteps:         25, // Default as per Spec
+		Nodes:              make(map[string]NodeFunc),
+		Edges:              make(map[string]string),
+		ConditionalEdges:   make(map[string]func(s State) string),
+		ConditionalTargets: make(map[string][]string),
+		NodeConfigs:        make(map[string]NodeConfig),
+		MaxSteps:           25, // Default as per Spec
 	}
 }
 
 // --- Builder Methods ---
-// Add Node
-func (g *Graph) AddNode(name string, node NodeFunc) {
+// Add Node, optionally with a retry, timeout or fallback policy
+func (g *Graph) AddNode(name string, node NodeFunc, opts ...NodeOption) {
 	g.Nodes[name] = node
+	if len(opts) == 0 {
+		return
+	}
+
+	var cfg NodeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if g.NodeConfigs == nil {
+		g.NodeConfigs = make(map[string]NodeConfig)
+	}
+	g.NodeConfigs[name] = cfg
 }
 
 // Add an edge
@@ -134,9 +176,17 @@ func (g *Graph) AddEdge(source, target string) {
 	g.Edges[source] = target
 }
 
-// Setting a conditional edge
-func (g *Graph) SetConditionalEdge(sourceNode string, logic func(s State) string) {
+// Setting a conditional edge. The optional targets declare every node the
+// logic may return, which Validate and the renderers rely on.
+func (g *Graph) SetConditionalEdge(sourceNode string, logic func(s State) string, targets ...string) {
 	g.ConditionalEdges[sourceNode] = logic
+	if len(targets) == 0 {
+		return
+	}
+	if g.ConditionalTargets == nil {
+		g.ConditionalTargets = make(map[string][]string)
+	}
+	g.ConditionalTargets[sourceNode] = targets
 }
 
 // Set entry point
@@ -172,7 +222,7 @@ func Run(ctx context.Context, endpointURL string, payload ModelRequest, token st
 	// 4. check response status code
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("received non-200 status: %d - %s", resp.StatusCode, string(bodyBytes))
+		return nil, &StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	// Create an empty response struct
@@ -190,70 +240,153 @@ func Run(ctx context.Context, endpointURL string, payload ModelRequest, token st
 
 // Execute runs the graph from its entry point until a node signals completion.
 func (g *Graph) Execute(ctx context.Context, initialState State) (State, error) {
-	currentNodeName := g.Entry
+	state, _, err := g.ExecuteWithTrace(ctx, initialState)
+	return state, err
+}
+
+// ExecuteWithTrace is Execute that also returns the trace of the run: every
+// visited node with its duration, transition reason, LLM usage and error. The
+// trace is returned even when execution fails. When the graph runs inside
+// another graph's node, such as a SubGraphNode, its trace is also attached to
+// that step's SubTraces.
+func (g *Graph) ExecuteWithTrace(ctx context.Context, initialState State) (State, *Trace, error) {
+	if err := g.checkpoint(ctx, Checkpoint{Step: 0, Next: g.Entry}, initialState); err != nil {
+		return initialState, &Trace{}, err
+	}
+	return g.run(ctx, g.Entry, initialState, 0)
+}
+
+// run executes the graph starting at start, with steps already counted
+// against MaxSteps (non-zero when resuming a fork).
+func (g *Graph) run(ctx context.Context, start string, initialState State, steps int) (State, *Trace, error) {
+	trace := &Trace{}
+	if parent := recorderFrom(ctx); parent != nil {
+		parent.addSubTrace(trace)
+	}
+
+	currentNodeName := start
 	state := initialState
-	steps := 0
+	var visited []string
+	// When running as a sub-graph, node paths are namespaced under the parent node.
+	prefix := NodePath(ctx)
+	ctx, cancel := g.budgetContext(ctx)
+	defer cancel()
+	// Middleware inherited from enclosing graphs is resolved once per run.
+	nodeCtx := g.middlewareContext(ctx)
 
 	for {
 		// 1. Strict Context Check
 		select {
 		case <-ctx.Done():
-			return state, ctx.Err()
+			if err := budgetCause(ctx); err != nil {
+				return state, trace, err
+			}
+			return state, trace, ctx.Err()
 		default:
 		}
 
 		// 2. Strict MaxSteps Check
 		if steps >= g.MaxSteps {
-			return state, ErrMaxStepsExceeded
+			return state, trace, newMaxStepsError(g.MaxSteps, visited)
 		}
 		steps++
 
 		// 3. Check for End of Execution via END magic string or empty
-		if currentNodeName == "END" || currentNodeName == "" {
-			return state, nil
+		if currentNodeName == END || currentNodeName == "" {
+			return state, trace, nil
 		}
 
 		// 4. Get and Execute Node
+		path := joinPath(prefix, currentNodeName)
 		node, exists := g.Nodes[currentNodeName]
 		if !exists {
-			return state, fmt.Errorf("node %s not found", currentNodeName)
+			return state, trace, fmt.Errorf("node %s not found", path)
 		}
+		visited = append(visited, currentNodeName)
+
+		rec := &stepRecorder{}
+		step := TraceStep{Node: currentNodeName, Path: path, Start: time.Now()}
+		stepCtx := context.WithValue(nodeContext(nodeCtx, path, g.MaxSteps-steps), stepRecorderKey{}, rec)
+
+		response, err := g.runNode(stepCtx, currentNodeName, g.wrapNode(ctx, currentNodeName, node), state)
+		step.Duration = time.Since(step.Start)
+		rec.finish(&step)
 
-		response, err := node(ctx, state)
 		if err != nil {
-			return state, fmt.Errorf("error executing node %s: %w", currentNodeName, err)
+			// A node cut short by the wall-clock budget reports the budget.
+			if cause := budgetCause(ctx); cause != nil {
+				err = cause
+			}
+			step.Error = err.Error()
+			// An error edge turns the failure into a transition to the fallback
+			// node, which starts from the pre-step state. A spent budget is
+			// final, so it never falls back.
+			if fallback := g.NodeConfigs[currentNodeName].Fallback; fallback != "" && ctx.Err() == nil && !errors.Is(err, ErrBudgetExceeded) {
+				step.Next, step.Reason = fallback, TransitionFallback
+				trace.Steps = append(trace.Steps, step)
+
+				state = response.State
+				state.Set("error", err.Error())
+				state.Set("error_node", path)
+				if err := g.checkpoint(ctx, Checkpoint{Step: steps, Node: currentNodeName, Next: fallback}, state); err != nil {
+					return state, trace, err
+				}
+				currentNodeName = fallback
+				continue
+			}
+			step.Reason = TransitionError
+			trace.Steps = append(trace.Steps, step)
+			return state, trace, wrapNodeError(path, err)
 		}
 
 		// Update state
 		state = response.State
 
 		// 5. Navigation Logic
-		// Priority 1: If NodeResult says IsDone, we stop immediately.
-		if response.IsDone {
-			return state, nil
+		step.Next, step.Reason = g.nextNode(currentNodeName, response, state)
+		trace.Steps = append(trace.Steps, step)
+		finished := step.Reason == TransitionDone || step.Reason == TransitionEnd
+		next := step.Next
+		if finished || next == "" {
+			next = END
 		}
-
-		// Priority 2: If NodeResult provides a specific NextNode, we go there.
-		if response.NextNode != "" {
-			currentNodeName = response.NextNode
-			continue
+		if err := g.checkpoint(ctx, Checkpoint{Step: steps, Node: currentNodeName, Next: next}, state); err != nil {
+			return state, trace, err
 		}
-
-		// Priority 3: Conditional Edges
-		if logic, exists := g.ConditionalEdges[currentNodeName]; exists {
-			currentNodeName = logic(state)
-			continue
+		if finished {
+			return state, trace, nil
 		}
-
-		// Priority 4: Static Edges
-		if nextNode, exists := g.Edges[currentNodeName]; exists {
-			currentNodeName = nextNode
-			continue
+		// Spending is judged between steps, so a step that overshoots a
+		// limit completes but the run does not go on.
+		if err := budgetFrom(ctx).check(true); err != nil {
+			return state, trace, err
 		}
+		currentNodeName = step.Next
+	}
+}
+
+// nextNode decides where execution goes after a successful step.
+func (g *Graph) nextNode(current string, response NodeResult, state State) (string, TransitionReason) {
+	// Priority 1: If NodeResult says IsDone, we stop immediately.
+	if response.IsDone {
+		return "", TransitionDone
+	}
+
+	// Priority 2: If NodeResult provides a specific NextNode, we go there.
+	if response.NextNode != "" {
+		return response.NextNode, TransitionNextNode
+	}
+
+	// Priority 3: Conditional Edges
+	if logic, exists := g.ConditionalEdges[current]; exists {
+		return logic(state), TransitionConditional
+	}
 
-		// Priority 5: No path found implies implicit termination
-		break
+	// Priority 4: Static Edges
+	if nextNode, exists := g.Edges[current]; exists {
+		return nextNode, TransitionEdge
 	}
 
-	return state, nil
+	// Priority 5: No path found implies implicit termination
+	return "", TransitionEnd
 }
@@ -0,0 +1,177 @@
+// in agora/budget.go
+
+package agora
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is matched by every BudgetError.
+var ErrBudgetExceeded = errors.New("execution budget exceeded")
+
+// Budget limits, reported in BudgetError.Limit.
+const (
+	LimitTokens    = "tokens"
+	LimitCost      = "cost"
+	LimitWallClock = "wall_clock"
+	LimitLLMCalls  = "llm_calls"
+	LimitToolCalls = "tool_calls"
+)
+
+// Budget caps what a single execution may spend. Zero fields are unlimited.
+// LLM usage is counted from ReportModelResponse and tool calls from
+// ReportToolCall, so nodes that do not report are not charged.
+type Budget struct {
+	MaxTokens    int
+	MaxCost      float64
+	Prices       PriceTable // Prices the calls counted against MaxCost
+	MaxWallClock time.Duration
+	MaxLLMCalls  int
+	MaxToolCalls int
+}
+
+func (b Budget) isZero() bool {
+	return b.MaxTokens == 0 && b.MaxCost == 0 && b.MaxWallClock == 0 && b.MaxLLMCalls == 0 && b.MaxToolCalls == 0
+}
+
+// BudgetError reports which limit of a Budget tripped. It matches
+// ErrBudgetExceeded with errors.Is.
+type BudgetError struct {
+	Limit string
+	Max   float64
+	Used  float64 // Seconds for LimitWallClock
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s: %s used %g of %g", ErrBudgetExceeded, e.Limit, e.Used, e.Max)
+}
+
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+type budgetKey struct{}
+
+// budgetTracker counts spending against a Budget. Sub-graphs and parallel
+// branches share their parent's tracker; a sub-graph with its own Budget gets
+// a tracker that also charges its parent's.
+type budgetTracker struct {
+	budget Budget
+	parent *budgetTracker
+	start  time.Time
+
+	mu        sync.Mutex
+	tokens    int
+	cost      float64
+	llmCalls  int
+	toolCalls int
+}
+
+func budgetFrom(ctx context.Context) *budgetTracker {
+	t, _ := ctx.Value(budgetKey{}).(*budgetTracker)
+	return t
+}
+
+// budgetContext attaches the graph's budget to ctx, enforcing MaxWallClock
+// with a deadline whose cause is a BudgetError.
+func (g *Graph) budgetContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if g.Budget.isZero() {
+		return ctx, func() {}
+	}
+	t := &budgetTracker{budget: g.Budget, parent: budgetFrom(ctx), start: time.Now()}
+	ctx = context.WithValue(ctx, budgetKey{}, t)
+	if g.Budget.MaxWallClock > 0 {
+		cause := &BudgetError{Limit: LimitWallClock, Max: g.Budget.MaxWallClock.Seconds(), Used: g.Budget.MaxWallClock.Seconds()}
+		return context.WithDeadlineCause(ctx, t.start.Add(g.Budget.MaxWallClock), cause)
+	}
+	return context.WithCancel(ctx)
+}
+
+// budgetCause returns the BudgetError that cancelled ctx, if any.
+func budgetCause(ctx context.Context) error {
+	var budgetErr *BudgetError
+	if errors.As(context.Cause(ctx), &budgetErr) {
+		return budgetErr
+	}
+	return nil
+}
+
+// charge records an LLM call against this tracker and its ancestors.
+func (t *budgetTracker) charge(resp ModelResponse) {
+	for ; t != nil; t = t.parent {
+		cost, _ := t.budget.Prices.Cost(resp.Model, resp.Usage)
+		t.mu.Lock()
+		t.llmCalls++
+		t.tokens += resp.Usage.TotalTokens
+		t.cost += cost
+		t.mu.Unlock()
+	}
+}
+
+// check reports the first limit that is used up. With spent set, a limit
+// only trips once it has been overshot, which is how a finished step is
+// judged; otherwise reaching it is enough to refuse further work.
+func (t *budgetTracker) check(spent bool) error {
+	for ; t != nil; t = t.parent {
+		t.mu.Lock()
+		b := t.budget
+		limits := []struct {
+			name      string
+			max, used float64
+		}{
+			{LimitTokens, float64(b.MaxTokens), float64(t.tokens)},
+			{LimitCost, b.MaxCost, t.cost},
+			{LimitLLMCalls, float64(b.MaxLLMCalls), float64(t.llmCalls)},
+			{LimitToolCalls, float64(b.MaxToolCalls), float64(t.toolCalls)},
+		}
+		t.mu.Unlock()
+
+		for _, l := range limits {
+			if l.max > 0 && (l.used > l.max || (!spent && l.used >= l.max)) {
+				return &BudgetError{Limit: l.name, Max: l.max, Used: l.used}
+			}
+		}
+	}
+	return nil
+}
+
+// CheckBudget returns a BudgetError when the execution in ctx has used up a
+// token, cost or LLM call limit. LLM nodes call it before every model call.
+func CheckBudget(ctx context.Context) error {
+	if err := budgetCause(ctx); err != nil {
+		return err
+	}
+	if t := budgetFrom(ctx); t != nil {
+		return t.check(false)
+	}
+	return nil
+}
+
+// ReportToolCall counts a tool call against the execution in ctx, or returns
+// a BudgetError without counting it when MaxToolCalls is used up.
+func ReportToolCall(ctx context.Context) error {
+	var chain []*budgetTracker
+	for t := budgetFrom(ctx); t != nil; t = t.parent {
+		chain = append(chain, t)
+	}
+	// The whole chain is locked, root first, so that concurrent branches
+	// cannot all pass the check before any of them is counted.
+	for i := len(chain) - 1; i >= 0; i-- {
+		chain[i].mu.Lock()
+		defer chain[i].mu.Unlock()
+	}
+
+	for _, t := range chain {
+		if max := t.budget.MaxToolCalls; max > 0 && t.toolCalls >= max {
+			return &BudgetError{Limit: LimitToolCalls, Max: float64(max), Used: float64(t.toolCalls)}
+		}
+	}
+	for _, t := range chain {
+		t.toolCalls++
+	}
+	return nil
+}
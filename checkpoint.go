@@ -0,0 +1,202 @@
+// in agora/checkpoint.go
+
+package agora
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCheckpointNotFound is returned when a run has no checkpoint at the requested step.
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// Checkpoint is the serialized state of a run after one of its steps.
+type Checkpoint struct {
+	RunID string `json:"run_id"`
+	// Step is the number of steps completed. Step 0 is the initial state.
+	Step int `json:"step"`
+	// Node is the node that produced this state. It is empty for the initial
+	// state and for the first checkpoint of a fork.
+	Node string `json:"node,omitempty"`
+	// Next is the node execution continues at, or END when the run finished.
+	Next  string          `json:"next"`
+	State json.RawMessage `json:"state"`
+	// ParentRunID and ParentStep are set on the first checkpoint of a fork.
+	ParentRunID string    `json:"parent_run_id,omitempty"`
+	ParentStep  int       `json:"parent_step,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Checkpointer persists checkpoints. pkg/storage provides a SQLite
+// implementation; MemoryCheckpointer is an in-process one.
+type Checkpointer interface {
+	SaveCheckpoint(ctx context.Context, cp Checkpoint) error
+	LoadCheckpoint(ctx context.Context, runID string, step int) (Checkpoint, error)
+	ListCheckpoints(ctx context.Context, runID string) ([]Checkpoint, error)
+}
+
+type runIDKey struct{}
+
+// WithRunID tags the execution in ctx with a run ID. A graph with a
+// Checkpointer only writes checkpoints for runs that have one.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunID returns the run ID set with WithRunID, or "".
+func RunID(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// ForkRequest describes a new run branched off a checkpoint of an old one.
+type ForkRequest struct {
+	// RunID and Step identify the checkpoint to fork from.
+	RunID string
+	Step  int
+	// NewRunID identifies the forked run. It must differ from RunID.
+	NewRunID string
+	// State is an empty value of the run's concrete state type that the
+	// checkpoint is decoded into, e.g. &ConversationState{}.
+	State State
+	// Edit optionally changes the state before execution resumes, e.g. to
+	// rewrite a tool result or the user input.
+	Edit func(s State) error
+	// Next optionally overrides the node execution resumes at.
+	Next string
+}
+
+// Checkpoints lists the checkpoints of a run in step order.
+func (g *Graph) Checkpoints(ctx context.Context, runID string) ([]Checkpoint, error) {
+	if g.Checkpointer == nil {
+		return nil, fmt.Errorf("graph has no checkpointer")
+	}
+	return g.Checkpointer.ListCheckpoints(ctx, runID)
+}
+
+// StateAt decodes the state of a run after the given step into into.
+func (g *Graph) StateAt(ctx context.Context, runID string, step int, into State) (State, error) {
+	if g.Checkpointer == nil {
+		return nil, fmt.Errorf("graph has no checkpointer")
+	}
+	cp, err := g.Checkpointer.LoadCheckpoint(ctx, runID, step)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(cp.State, into); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint state: %w", err)
+	}
+	return into, nil
+}
+
+// Fork starts a new run from a checkpoint of a previous one, leaving the
+// original untouched. The forked run keeps the original's step count, so
+// MaxSteps applies across both, and records its parent in its first checkpoint.
+func (g *Graph) Fork(ctx context.Context, req ForkRequest) (State, error) {
+	state, _, err := g.ForkWithTrace(ctx, req)
+	return state, err
+}
+
+// ForkWithTrace is Fork that also returns the trace of the forked run.
+func (g *Graph) ForkWithTrace(ctx context.Context, req ForkRequest) (State, *Trace, error) {
+	if req.NewRunID == "" || req.NewRunID == req.RunID {
+		return nil, &Trace{}, fmt.Errorf("fork needs a new run ID")
+	}
+
+	// 1. Restore the state at the requested step.
+	state, err := g.StateAt(ctx, req.RunID, req.Step, req.State)
+	if err != nil {
+		return nil, &Trace{}, err
+	}
+	cp, err := g.Checkpointer.LoadCheckpoint(ctx, req.RunID, req.Step)
+	if err != nil {
+		return nil, &Trace{}, err
+	}
+
+	// 2. Apply the caller's edits.
+	if req.Edit != nil {
+		if err := req.Edit(state); err != nil {
+			return nil, &Trace{}, fmt.Errorf("failed to edit forked state: %w", err)
+		}
+	}
+	next := cp.Next
+	if req.Next != "" {
+		next = req.Next
+	}
+
+	// 3. Record the lineage and resume.
+	ctx = WithRunID(ctx, req.NewRunID)
+	if err := g.checkpoint(ctx, Checkpoint{Step: req.Step, Next: next, ParentRunID: req.RunID, ParentStep: req.Step}, state); err != nil {
+		return state, &Trace{}, err
+	}
+	return g.run(ctx, next, state, req.Step)
+}
+
+// checkpoint saves state for the run in ctx. Graphs running inside another
+// graph's node are covered by the enclosing graph's checkpoints.
+func (g *Graph) checkpoint(ctx context.Context, cp Checkpoint, state State) error {
+	if g.Checkpointer == nil || NodePath(ctx) != "" || RunID(ctx) == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to serialize checkpoint state: %w", err)
+	}
+	cp.RunID = RunID(ctx)
+	cp.State = data
+	cp.CreatedAt = time.Now()
+	if err := g.Checkpointer.SaveCheckpoint(ctx, cp); err != nil {
+		return fmt.Errorf("failed to save checkpoint %d: %w", cp.Step, err)
+	}
+	return nil
+}
+
+// MemoryCheckpointer keeps checkpoints in memory.
+type MemoryCheckpointer struct {
+	mu   sync.Mutex
+	runs map[string][]Checkpoint
+}
+
+// NewMemoryCheckpointer creates an empty MemoryCheckpointer.
+func NewMemoryCheckpointer() *MemoryCheckpointer {
+	return &MemoryCheckpointer{runs: make(map[string][]Checkpoint)}
+}
+
+// SaveCheckpoint stores cp, replacing any checkpoint of the same run and step.
+func (m *MemoryCheckpointer) SaveCheckpoint(ctx context.Context, cp Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	run := m.runs[cp.RunID]
+	for i := range run {
+		if run[i].Step == cp.Step {
+			run[i] = cp
+			return nil
+		}
+	}
+	m.runs[cp.RunID] = append(run, cp)
+	return nil
+}
+
+// LoadCheckpoint returns the checkpoint of a run at step.
+func (m *MemoryCheckpointer) LoadCheckpoint(ctx context.Context, runID string, step int) (Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, cp := range m.runs[runID] {
+		if cp.Step == step {
+			return cp, nil
+		}
+	}
+	return Checkpoint{}, fmt.Errorf("run %s step %d: %w", runID, step, ErrCheckpointNotFound)
+}
+
+// ListCheckpoints returns the checkpoints of a run in step order.
+func (m *MemoryCheckpointer) ListCheckpoints(ctx context.Context, runID string) ([]Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Checkpoint(nil), m.runs[runID]...), nil
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/amangsingh/agora/pkg/compiler"
+	"github.com/spf13/cobra"
+)
+
+var graphFormat string
+
+// graphCmd represents the graph command
+var graphCmd = &cobra.Command{
+	Use:   "graph [blueprint-file]",
+	Short: "Render a blueprint's graph as Mermaid or Graphviz DOT",
+	Long: `Reads the specified YAML blueprint (default: agora.yaml) and prints
+its topology as a Mermaid flowchart or a Graphviz DOT digraph.
+
+Static edges are drawn solid; router branches are drawn dashed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		blueprintPath := "agora.yaml"
+		if len(args) > 0 {
+			blueprintPath = args[0]
+		}
+
+		out, err := compiler.RenderBlueprint(blueprintPath, graphFormat)
+		if err != nil {
+			return fmt.Errorf("could not render graph: %w", err)
+		}
+
+		fmt.Print(out)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().StringVarP(&graphFormat, "format", "f", "mermaid", "Output format: mermaid or dot")
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/amangsingh/agora/llm"
+	"github.com/amangsingh/agora/pkg/storage"
+	"github.com/amangsingh/agora/rag"
+	"github.com/amangsingh/agora/vectorstore"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ingestDB         string
+	ingestCollection string
+	ingestBaseURL    string
+	ingestModel      string
+	ingestToken      string
+	ingestChunkSize  int
+	ingestOverlap    int
+	ingestHeadings   bool
+)
+
+// ingestCmd represents the ingest command
+var ingestCmd = &cobra.Command{
+	Use:   "ingest [paths...]",
+	Short: "Chunk, embed and store documents for retrieval",
+	Long: `Loads the given text, Markdown and HTML files (directories are walked
+recursively), splits them into chunks, embeds the chunks and stores them in
+a vector collection of the SQLite database used by agora-server.
+
+Embeddings come from Ollama unless --token is set, in which case --base-url
+and --model name any OpenAI-compatible API and must be given. Re-ingesting a
+file replaces its chunks.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if ingestToken != "" && (ingestBaseURL == "" || ingestModel == "") {
+			return fmt.Errorf("--token needs --base-url and --model, e.g. --base-url https://api.openai.com/v1 --model text-embedding-3-small")
+		}
+
+		sources, err := rag.LoadPaths(args...)
+		if err != nil {
+			return fmt.Errorf("could not load documents: %w", err)
+		}
+
+		repo, err := storage.NewRepository(ingestDB)
+		if err != nil {
+			return fmt.Errorf("could not open database: %w", err)
+		}
+
+		var embedder llm.Embedder = llm.NewOllamaEmbedder(ingestBaseURL, ingestModel)
+		if ingestToken != "" {
+			embedder = llm.NewOpenAICompatibleEmbedder(ingestBaseURL, ingestModel, ingestToken)
+		}
+
+		ingester := rag.NewIngester(embedder, repo.VectorStore(ingestCollection, vectorstore.Cosine))
+		ingester.Chunking = rag.ChunkConfig{Size: ingestChunkSize, Overlap: ingestOverlap, Headings: ingestHeadings}
+		if ingestOverlap == 0 {
+			ingester.Chunking.Overlap = -1 // explicitly none, not the default
+		}
+
+		total := 0
+		for _, src := range sources {
+			n, err := ingester.Ingest(context.Background(), src)
+			if err != nil {
+				return err
+			}
+			if verbose {
+				fmt.Printf("  %s: %d chunks\n", src.ID, n)
+			}
+			total += n
+		}
+		fmt.Printf("Ingested %d chunks from %d documents into '%s'.\n", total, len(sources), ingestCollection)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ingestCmd)
+
+	ingestCmd.Flags().StringVar(&ingestDB, "db", "agora.db", "SQLite database path")
+	ingestCmd.Flags().StringVarP(&ingestCollection, "collection", "c", "documents", "Vector collection name")
+	ingestCmd.Flags().StringVar(&ingestBaseURL, "base-url", "", "Embeddings API base URL (default: local Ollama; required with --token)")
+	ingestCmd.Flags().StringVarP(&ingestModel, "model", "m", "", "Embedding model (default: $OLLAMA_EMBED_MODEL or nomic-embed-text)")
+	ingestCmd.Flags().StringVar(&ingestToken, "token", "", "API token for an OpenAI-compatible embeddings API")
+	ingestCmd.Flags().IntVar(&ingestChunkSize, "chunk-size", 1000, "Maximum chunk length in characters")
+	ingestCmd.Flags().IntVar(&ingestOverlap, "overlap", 200, "Characters shared by consecutive chunks")
+	ingestCmd.Flags().BoolVar(&ingestHeadings, "headings", false, "Start a new chunk at every heading")
+}
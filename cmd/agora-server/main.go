@@ -1,13 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 
+	"github.com/amangsingh/agora"
 	"github.com/amangsingh/agora/pkg/server"
 	"github.com/amangsingh/agora/pkg/storage"
+	"github.com/amangsingh/agora/redact"
 	"github.com/rs/cors"
 )
 
@@ -29,13 +32,39 @@ func main() {
 	}
 	log.Printf("Storage initialized at %s", dbPath)
 
+	// Optional price table for cost accounting, as JSON:
+	// {"gpt-4o": {"input_per_million": 2.5, "output_per_million": 10}}
+	var prices agora.PriceTable
+	if pricesPath := os.Getenv("AGORA_PRICES"); pricesPath != "" {
+		data, err := os.ReadFile(pricesPath)
+		if err != nil {
+			log.Fatalf("Failed to read price table: %v", err)
+		}
+		if err := json.Unmarshal(data, &prices); err != nil {
+			log.Fatalf("Failed to parse price table: %v", err)
+		}
+	}
+
+	// Optional redaction of personal data and secrets, in provider calls and
+	// in everything persisted.
+	var redactor *redact.Redactor
+	if os.Getenv("AGORA_REDACT") == "true" {
+		redactor = redact.New()
+		repo.SetRedactor(redactor.Redact)
+		log.Println("Redaction enabled")
+	}
+
 	// 3. Handlers
-	handler := &server.AgentHandler{Repo: repo}
+	handler := &server.AgentHandler{Repo: repo, Prices: prices, Redactor: redactor}
 
 	// 4. Router
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /run", handler.HandleRun)
 	mux.HandleFunc("GET /history", handler.HandleGetHistory)
+	mux.HandleFunc("GET /steps", handler.HandleGetSteps)
+	mux.HandleFunc("POST /fork", handler.HandleFork)
+	mux.HandleFunc("GET /usage", handler.HandleGetUsage)
+	mux.HandleFunc("GET /usage/keys", handler.HandleGetKeyUsage)
 
 	// 5. Middleware Chain
 	// Apply CORS
@@ -0,0 +1,218 @@
+// in agora/content.go
+
+package agora
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Content part types.
+const (
+	PartText  = "text"
+	PartImage = "image"
+	PartFile  = "file"
+)
+
+// ContentPart is one piece of a multimodal message: text, an image or a file.
+// Images are given by URL or inline as Data with a MIMEType; files inline or
+// by the FileID of an upload to the provider.
+type ContentPart struct {
+	Type     string // PartText, PartImage or PartFile
+	Text     string
+	URL      string
+	Data     []byte
+	MIMEType string
+	// FileID references a file already uploaded to the provider.
+	FileID   string
+	Filename string
+	// Detail is the image resolution hint: "low", "high" or "auto".
+	Detail string
+}
+
+// TextPart creates a text part.
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: PartText, Text: text}
+}
+
+// ImageURLPart creates an image part the provider fetches from url.
+func ImageURLPart(url string) ContentPart {
+	return ContentPart{Type: PartImage, URL: url}
+}
+
+// ImageDataPart creates an inline image part, e.g. a screenshot.
+func ImageDataPart(mimeType string, data []byte) ContentPart {
+	return ContentPart{Type: PartImage, MIMEType: mimeType, Data: data}
+}
+
+// FileDataPart creates an inline file part, e.g. a PDF.
+func FileDataPart(filename, mimeType string, data []byte) ContentPart {
+	return ContentPart{Type: PartFile, Filename: filename, MIMEType: mimeType, Data: data}
+}
+
+// FileIDPart creates a part referencing a file uploaded to the provider.
+func FileIDPart(fileID string) ContentPart {
+	return ContentPart{Type: PartFile, FileID: fileID}
+}
+
+// NewMultimodalMessage creates a message from parts. Content is set to their
+// text, so code reading only Content still sees what was said.
+func NewMultimodalMessage(role string, parts ...ContentPart) ChatMessage {
+	return ChatMessage{Role: role, Content: partsText(parts), Parts: parts}
+}
+
+// Text returns the message's text: Content, or the text parts joined by
+// newlines for a multimodal message.
+func (m ChatMessage) Text() string {
+	if len(m.Parts) == 0 {
+		return m.Content
+	}
+	return partsText(m.Parts)
+}
+
+func partsText(parts []ContentPart) string {
+	var texts []string
+	for _, p := range parts {
+		if p.Type == PartText {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// DataURL encodes inline data as a data: URL.
+func DataURL(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// ParseDataURL decodes a base64 data: URL.
+func ParseDataURL(url string) (mimeType string, data []byte, ok bool) {
+	rest, found := strings.CutPrefix(url, "data:")
+	if !found {
+		return "", nil, false
+	}
+	meta, payload, found := strings.Cut(rest, ",")
+	if !found || !strings.HasSuffix(meta, ";base64") {
+		return "", nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", nil, false
+	}
+	return strings.TrimSuffix(meta, ";base64"), data, true
+}
+
+// The JSON form of a part is the Chat Completions one, which most providers
+// accept, so requests go over the wire unchanged.
+type contentPartJSON struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *imageURLJSON `json:"image_url,omitempty"`
+	File     *fileRefJSON  `json:"file,omitempty"`
+}
+
+type imageURLJSON struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type fileRefJSON struct {
+	FileID   string `json:"file_id,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	FileData string `json:"file_data,omitempty"`
+}
+
+// MarshalJSON encodes the part in the Chat Completions format.
+func (p ContentPart) MarshalJSON() ([]byte, error) {
+	switch p.Type {
+	case PartText:
+		return json.Marshal(contentPartJSON{Type: "text", Text: p.Text})
+	case PartImage:
+		url := p.URL
+		if len(p.Data) > 0 {
+			url = DataURL(p.MIMEType, p.Data)
+		}
+		return json.Marshal(contentPartJSON{Type: "image_url", ImageURL: &imageURLJSON{URL: url, Detail: p.Detail}})
+	case PartFile:
+		file := &fileRefJSON{FileID: p.FileID, Filename: p.Filename}
+		if len(p.Data) > 0 {
+			file.FileData = DataURL(p.MIMEType, p.Data)
+		}
+		return json.Marshal(contentPartJSON{Type: "file", File: file})
+	default:
+		return nil, fmt.Errorf("unknown content part type %q", p.Type)
+	}
+}
+
+// UnmarshalJSON decodes a part in the Chat Completions format, turning data:
+// URLs back into Data and MIMEType.
+func (p *ContentPart) UnmarshalJSON(data []byte) error {
+	var raw contentPartJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch {
+	case raw.Type == "text":
+		*p = TextPart(raw.Text)
+	case raw.Type == "image_url" && raw.ImageURL != nil:
+		*p = ContentPart{Type: PartImage, URL: raw.ImageURL.URL, Detail: raw.ImageURL.Detail}
+		if mimeType, decoded, ok := ParseDataURL(raw.ImageURL.URL); ok {
+			p.URL, p.MIMEType, p.Data = "", mimeType, decoded
+		}
+	case raw.Type == "file" && raw.File != nil:
+		*p = ContentPart{Type: PartFile, FileID: raw.File.FileID, Filename: raw.File.Filename}
+		if mimeType, decoded, ok := ParseDataURL(raw.File.FileData); ok {
+			p.MIMEType, p.Data = mimeType, decoded
+		}
+	default:
+		return fmt.Errorf("unsupported content part type %q", raw.Type)
+	}
+	return nil
+}
+
+// chatMessageJSON has ChatMessage's fields without its JSON methods.
+type chatMessageJSON ChatMessage
+
+// MarshalJSON encodes Content as a string, exactly as before parts existed,
+// unless the message has Parts: then content is the list of parts.
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Parts) == 0 {
+		return json.Marshal(chatMessageJSON(m))
+	}
+	return json.Marshal(struct {
+		chatMessageJSON
+		Content []ContentPart `json:"content"`
+	}{chatMessageJSON(m), m.Parts})
+}
+
+// UnmarshalJSON accepts content as a string or as a list of parts. For parts,
+// Content is set to their text.
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		chatMessageJSON
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = ChatMessage(raw.chatMessageJSON)
+
+	content := bytes.TrimSpace(raw.Content)
+	switch {
+	case len(content) == 0 || bytes.Equal(content, []byte("null")):
+		m.Content = ""
+	case content[0] == '[':
+		if err := json.Unmarshal(content, &m.Parts); err != nil {
+			return fmt.Errorf("invalid message content: %w", err)
+		}
+		m.Content = partsText(m.Parts)
+	default:
+		if err := json.Unmarshal(content, &m.Content); err != nil {
+			return fmt.Errorf("invalid message content: %w", err)
+		}
+	}
+	return nil
+}
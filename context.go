@@ -0,0 +1,69 @@
+// in agora/context.go
+
+package agora
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NodeError is returned by Execute when a node fails. Path is the namespaced
+// location of the failing node, e.g. "parent/child/node" when the failure
+// happened inside a sub-graph.
+type NodeError struct {
+	Path string
+	Err  error
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("error executing node %s: %v", e.Path, e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+type nodePathKey struct{}
+type remainingStepsKey struct{}
+
+// NodePath returns the namespaced path of the node currently executing in ctx,
+// such as "parent/child/node". It is empty outside of Graph.Execute.
+func NodePath(ctx context.Context) string {
+	path, _ := ctx.Value(nodePathKey{}).(string)
+	return path
+}
+
+// RemainingSteps reports how many steps the innermost executing graph has left
+// after the current one. The boolean is false outside of Graph.Execute.
+func RemainingSteps(ctx context.Context) (int, bool) {
+	remaining, ok := ctx.Value(remainingStepsKey{}).(int)
+	return remaining, ok
+}
+
+// joinPath appends a node name to a namespaced path.
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+// nodeContext derives the context a node runs under, carrying its path and
+// the step budget left in its graph.
+func nodeContext(ctx context.Context, path string, remaining int) context.Context {
+	ctx = context.WithValue(ctx, nodePathKey{}, path)
+	return context.WithValue(ctx, remainingStepsKey{}, remaining)
+}
+
+// wrapNodeError attributes err to the node at path. Errors that were already
+// attributed to a node nested below path (by a sub-graph) are passed through
+// so the deepest path is reported once.
+func wrapNodeError(path string, err error) error {
+	var nodeErr *NodeError
+	if errors.As(err, &nodeErr) && strings.HasPrefix(nodeErr.Path, path+"/") {
+		return err
+	}
+	return &NodeError{Path: path, Err: err}
+}
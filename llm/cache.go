@@ -0,0 +1,323 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/amangsingh/agora"
+)
+
+// CacheEntry is a cached model response. Chunks holds the streamed chunks
+// when the response was produced by Stream, so a hit can be replayed as a stream.
+type CacheEntry struct {
+	Response  agora.ModelResponse   `json:"response"`
+	Chunks    []agora.ModelResponse `json:"chunks,omitempty"`
+	ExpiresAt time.Time             `json:"expires_at"` // Zero means the entry never expires
+}
+
+// CacheStore persists cache entries. Expiry is enforced by CachingLLM, so
+// stores only need to keep what they are given. pkg/storage provides a
+// SQLite store.
+type CacheStore interface {
+	Get(ctx context.Context, key string) (CacheEntry, bool, error)
+	Set(ctx context.Context, key string, entry CacheEntry) error
+}
+
+// CacheConfig configures a CachingLLM.
+type CacheConfig struct {
+	Store CacheStore
+	// TTL is how long entries stay valid. Zero means forever.
+	TTL time.Duration
+	// Namespace is mixed into every key. Set it when several models share a
+	// store, since requests often leave Model to the wrapped LLM.
+	Namespace string
+	// CacheNonDeterministic also caches requests that ask for sampling
+	// (Temperature > 0 without a Seed). By default they bypass the cache.
+	CacheNonDeterministic bool
+}
+
+// CacheStats counts cache outcomes. StoreErrors counts failed reads and
+// writes; they never fail the call itself, which falls through to the model.
+type CacheStats struct {
+	Hits        int64
+	Misses      int64
+	Bypassed    int64
+	StoreErrors int64
+}
+
+// CachingLLM is an LLM decorator that serves repeated requests from a cache.
+type CachingLLM struct {
+	next LLM
+	cfg  CacheConfig
+
+	hits, misses, bypassed, storeErrors atomic.Int64
+}
+
+// NewCachingLLM wraps next with a response cache.
+func NewCachingLLM(next LLM, cfg CacheConfig) *CachingLLM {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryCache(1000)
+	}
+	return &CachingLLM{next: next, cfg: cfg}
+}
+
+type bypassCacheKey struct{}
+
+// WithoutCache makes CachingLLM skip the cache for calls made with ctx.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+// Stats returns the hit, miss and bypass counts so far.
+func (c *CachingLLM) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Bypassed: c.bypassed.Load(), StoreErrors: c.storeErrors.Load()}
+}
+
+// Invoke implements the LLM interface.
+func (c *CachingLLM) Invoke(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+	key, ok, err := c.lookupKey(ctx, request)
+	if err != nil || !ok {
+		return c.next.Invoke(ctx, request)
+	}
+
+	if entry, hit := c.get(ctx, key); hit {
+		return entry.Response, nil
+	}
+
+	response, err := c.next.Invoke(ctx, request)
+	if err != nil {
+		return response, err
+	}
+	c.set(ctx, key, CacheEntry{Response: response})
+	return response, nil
+}
+
+// Stream implements StreamingLLM. A hit replays the cached chunks.
+func (c *CachingLLM) Stream(ctx context.Context, request agora.ModelRequest, onChunk func(chunk agora.ModelResponse) error) (agora.ModelResponse, error) {
+	key, ok, err := c.lookupKey(ctx, request)
+	if err != nil || !ok {
+		return Stream(ctx, c.next, request, onChunk)
+	}
+
+	if entry, hit := c.get(ctx, key); hit {
+		chunks := entry.Chunks
+		if len(chunks) == 0 {
+			// Cached by Invoke: deliver the whole answer as one chunk.
+			return Stream(ctx, staticLLM(entry.Response), request, onChunk)
+		}
+		for _, chunk := range chunks {
+			if err := onChunk(chunk); err != nil {
+				return agora.ModelResponse{}, err
+			}
+		}
+		return entry.Response, nil
+	}
+
+	var chunks []agora.ModelResponse
+	response, err := Stream(ctx, c.next, request, func(chunk agora.ModelResponse) error {
+		chunks = append(chunks, chunk)
+		return onChunk(chunk)
+	})
+	if err != nil {
+		return response, err
+	}
+	c.set(ctx, key, CacheEntry{Response: response, Chunks: chunks})
+	return response, nil
+}
+
+// lookupKey returns the cache key, or false when the request bypasses the cache.
+func (c *CachingLLM) lookupKey(ctx context.Context, request agora.ModelRequest) (string, bool, error) {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	if bypass || (!c.cfg.CacheNonDeterministic && IsNonDeterministic(request)) {
+		c.bypassed.Add(1)
+		return "", false, nil
+	}
+	key, err := RequestKey(request)
+	if err != nil {
+		return "", false, err
+	}
+	if c.cfg.Namespace != "" {
+		key = c.cfg.Namespace + ":" + key
+	}
+	return key, true, nil
+}
+
+func (c *CachingLLM) get(ctx context.Context, key string) (CacheEntry, bool) {
+	entry, ok, err := c.cfg.Store.Get(ctx, key)
+	if err != nil {
+		c.storeErrors.Add(1)
+	}
+	if err != nil || !ok || (!entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt)) {
+		c.misses.Add(1)
+		return CacheEntry{}, false
+	}
+	c.hits.Add(1)
+	return entry, true
+}
+
+func (c *CachingLLM) set(ctx context.Context, key string, entry CacheEntry) {
+	if c.cfg.TTL > 0 {
+		entry.ExpiresAt = time.Now().Add(c.cfg.TTL)
+	}
+	if err := c.cfg.Store.Set(ctx, key, entry); err != nil {
+		c.storeErrors.Add(1)
+	}
+}
+
+// IsNonDeterministic reports whether a request asks for sampling without a
+// seed, so repeating it is expected to give different answers.
+func IsNonDeterministic(request agora.ModelRequest) bool {
+	return request.Temperature != nil && *request.Temperature > 0 && request.Seed == nil
+}
+
+// RequestKey is the canonical SHA-256 of a request: model, messages, tools
+// and generation parameters. Tools are sorted by name because registries hand
+// them out in map order, and Stream is ignored so streamed and non-streamed
+// calls share entries.
+func RequestKey(request agora.ModelRequest) (string, error) {
+	tools := append([]agora.ToolDefinition(nil), request.Tools...)
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Function.Name < tools[j].Function.Name
+	})
+	request.Tools = tools
+	request.Stream = false
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request for hashing: %w", err)
+	}
+	// Round-trip through a generic value so map keys are always sorted.
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return "", fmt.Errorf("failed to normalize request: %w", err)
+	}
+	if data, err = json.Marshal(generic); err != nil {
+		return "", fmt.Errorf("failed to normalize request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// staticLLM always answers with the same response.
+type staticLLM agora.ModelResponse
+
+func (s staticLLM) Invoke(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+	return agora.ModelResponse(s), nil
+}
+
+// MemoryCache is an in-memory CacheStore that evicts the least recently used
+// entry once it holds more than its capacity.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Front is most recently used
+	items    map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// NewMemoryCache creates an LRU cache holding up to capacity entries.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{capacity: max(capacity, 1), order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get implements CacheStore.
+func (m *MemoryCache) Get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.items[key]
+	if !ok {
+		return CacheEntry{}, false, nil
+	}
+	m.order.MoveToFront(el)
+	return el.Value.(*memoryCacheItem).entry, true, nil
+}
+
+// Set implements CacheStore.
+func (m *MemoryCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.items[key]; ok {
+		el.Value.(*memoryCacheItem).entry = entry
+		m.order.MoveToFront(el)
+		return nil
+	}
+	m.items[key] = m.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+	for m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
+
+// DiskCache is a CacheStore keeping one JSON file per entry in a directory.
+type DiskCache struct {
+	Dir string
+}
+
+// NewDiskCache creates a disk cache in dir, creating it if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &DiskCache{Dir: dir}, nil
+}
+
+// Get implements CacheStore.
+func (d *DiskCache) Get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return CacheEntry{}, false, nil
+	}
+	if err != nil {
+		return CacheEntry{}, false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, false, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+	return entry, true, nil
+}
+
+// Set implements CacheStore. Entries are written to a temporary file and
+// renamed so concurrent readers never see a partial entry.
+func (d *DiskCache) Set(ctx context.Context, key string, entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	tmp, err := os.CreateTemp(d.Dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return os.Rename(tmp.Name(), d.path(key))
+}
+
+// path hashes the key again so namespaced keys are always valid file names.
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.Dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,350 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/amangsingh/agora"
+)
+
+// ErrCircuitOpen is returned by a CircuitBreakerLLM while its backend is ejected.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Backend is a named LLM taking part in a composite.
+type Backend struct {
+	Name string
+	LLM  LLM
+	// Weight is the backend's share of traffic under RoundRobin. Defaults to 1.
+	Weight int
+}
+
+// served records which backend answered, unless a nested composite already did.
+func served(resp agora.ModelResponse, name string) agora.ModelResponse {
+	if resp.Backend == "" {
+		resp.Backend = name
+	}
+	return resp
+}
+
+// FallbackLLM tries its backends in order, moving to the next one when a
+// backend fails with a retryable error or has its circuit open.
+type FallbackLLM struct {
+	Backends []Backend
+	// Retryable decides which errors move on to the next backend. Defaults to
+	// agora.IsRetryable plus ErrCircuitOpen.
+	Retryable func(err error) bool
+}
+
+// NewFallbackLLM creates a FallbackLLM over the given backends, in priority order.
+func NewFallbackLLM(backends ...Backend) *FallbackLLM {
+	return &FallbackLLM{Backends: backends}
+}
+
+// Invoke implements the LLM interface.
+func (f *FallbackLLM) Invoke(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+	return f.try(ctx, func(b Backend) (agora.ModelResponse, bool, error) {
+		resp, err := b.LLM.Invoke(ctx, request)
+		return resp, false, err
+	})
+}
+
+// Stream implements StreamingLLM. A backend that fails after it started
+// delivering chunks is not retried elsewhere, since the caller already saw
+// part of its answer.
+func (f *FallbackLLM) Stream(ctx context.Context, request agora.ModelRequest, onChunk func(chunk agora.ModelResponse) error) (agora.ModelResponse, error) {
+	return f.try(ctx, func(b Backend) (agora.ModelResponse, bool, error) {
+		delivered := false
+		resp, err := Stream(ctx, b.LLM, request, func(chunk agora.ModelResponse) error {
+			delivered = true
+			return onChunk(chunk)
+		})
+		return resp, delivered, err
+	})
+}
+
+// try calls each backend in turn until one succeeds or fails for good.
+func (f *FallbackLLM) try(ctx context.Context, call func(b Backend) (agora.ModelResponse, bool, error)) (agora.ModelResponse, error) {
+	if len(f.Backends) == 0 {
+		return agora.ModelResponse{}, fmt.Errorf("fallback has no backends")
+	}
+	retryable := f.Retryable
+	if retryable == nil {
+		retryable = func(err error) bool {
+			return agora.IsRetryable(err) || errors.Is(err, ErrCircuitOpen)
+		}
+	}
+
+	var errs []error
+	for _, b := range f.Backends {
+		resp, delivered, err := call(b)
+		if err == nil {
+			return served(resp, b.Name), nil
+		}
+		errs = append(errs, fmt.Errorf("backend %s: %w", b.Name, err))
+		if delivered || ctx.Err() != nil || !retryable(err) {
+			break
+		}
+	}
+	return agora.ModelResponse{}, fmt.Errorf("all fallback backends failed: %w", errors.Join(errs...))
+}
+
+// BalanceStrategy selects how a BalancedLLM spreads requests.
+type BalanceStrategy int
+
+const (
+	// RoundRobin rotates through the backends in proportion to their weights.
+	RoundRobin BalanceStrategy = iota
+	// LeastLatency sends each request to the backend with the lowest recent
+	// latency. A backend that has not answered yet gets one probe request
+	// first, and a backend that fails is left out for failureExclusion.
+	LeastLatency
+)
+
+// latencySmoothing is the weight of the newest sample in the latency average.
+const latencySmoothing = 0.3
+
+// failureExclusion is how long LeastLatency leaves out a failed backend.
+const failureExclusion = 5 * time.Second
+
+// BalancedLLM spreads requests across equivalent backends. It makes a single
+// attempt per request; wrap backends in CircuitBreakerLLM and the whole
+// composite in a FallbackLLM for resilience.
+type BalancedLLM struct {
+	strategy BalanceStrategy
+	backends []Backend
+
+	mu       sync.Mutex
+	current  []int           // smooth weighted round-robin counters
+	latency  []time.Duration // moving average per backend, zero until measured
+	probing  []bool          // an unmeasured backend's first request is in flight
+	excluded []time.Time     // failed backends are left out until then
+}
+
+// NewBalancedLLM creates a BalancedLLM using the given strategy.
+func NewBalancedLLM(strategy BalanceStrategy, backends ...Backend) *BalancedLLM {
+	return &BalancedLLM{
+		strategy: strategy,
+		backends: backends,
+		current:  make([]int, len(backends)),
+		latency:  make([]time.Duration, len(backends)),
+		probing:  make([]bool, len(backends)),
+		excluded: make([]time.Time, len(backends)),
+	}
+}
+
+// Invoke implements the LLM interface.
+func (b *BalancedLLM) Invoke(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+	i, err := b.pick()
+	if err != nil {
+		return agora.ModelResponse{}, err
+	}
+	start := time.Now()
+	resp, err := b.backends[i].LLM.Invoke(ctx, request)
+	return b.finish(i, start, resp, err)
+}
+
+// Stream implements StreamingLLM.
+func (b *BalancedLLM) Stream(ctx context.Context, request agora.ModelRequest, onChunk func(chunk agora.ModelResponse) error) (agora.ModelResponse, error) {
+	i, err := b.pick()
+	if err != nil {
+		return agora.ModelResponse{}, err
+	}
+	start := time.Now()
+	resp, err := Stream(ctx, b.backends[i].LLM, request, onChunk)
+	return b.finish(i, start, resp, err)
+}
+
+// pick chooses the backend for the next request.
+func (b *BalancedLLM) pick() (int, error) {
+	if len(b.backends) == 0 {
+		return 0, fmt.Errorf("balancer has no backends")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.strategy == LeastLatency {
+		now := time.Now()
+		best := -1
+		for i, l := range b.latency {
+			if now.Before(b.excluded[i]) {
+				continue
+			}
+			if l == 0 {
+				if !b.probing[i] {
+					b.probing[i] = true
+					return i, nil
+				}
+				continue
+			}
+			if best < 0 || l < b.latency[best] {
+				best = i
+			}
+		}
+		if best >= 0 {
+			return best, nil
+		}
+		// Every backend is excluded or being probed: take the one whose
+		// exclusion ends first.
+		best = 0
+		for i, until := range b.excluded {
+			if until.Before(b.excluded[best]) {
+				best = i
+			}
+		}
+		return best, nil
+	}
+
+	// Smooth weighted round-robin: every backend gains its weight, the
+	// leader is picked and pays back the total.
+	total, best := 0, 0
+	for i, backend := range b.backends {
+		weight := max(backend.Weight, 1)
+		total += weight
+		b.current[i] += weight
+		if b.current[i] > b.current[best] {
+			best = i
+		}
+	}
+	b.current[best] -= total
+	return best, nil
+}
+
+// finish records the latency of a successful call, or excludes the backend
+// after a failed one, and names the backend.
+func (b *BalancedLLM) finish(i int, start time.Time, resp agora.ModelResponse, err error) (agora.ModelResponse, error) {
+	name := b.backends[i].Name
+	b.mu.Lock()
+	b.probing[i] = false
+	if err != nil {
+		b.excluded[i] = time.Now().Add(failureExclusion)
+		b.mu.Unlock()
+		return agora.ModelResponse{}, fmt.Errorf("backend %s: %w", name, err)
+	}
+
+	elapsed := time.Since(start)
+	b.excluded[i] = time.Time{}
+	if b.latency[i] == 0 {
+		b.latency[i] = max(elapsed, 1)
+	} else {
+		b.latency[i] = time.Duration(latencySmoothing*float64(elapsed) + (1-latencySmoothing)*float64(b.latency[i]))
+	}
+	b.mu.Unlock()
+
+	return served(resp, name), nil
+}
+
+// CircuitBreakerConfig configures a CircuitBreakerLLM.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit. Defaults to 5.
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a trial request is
+	// let through. Defaults to 30 seconds.
+	Cooldown time.Duration
+	// IsFailure decides which errors count against the backend. Defaults to
+	// agora.IsRetryable, so bad requests do not eject a healthy backend.
+	IsFailure func(err error) bool
+}
+
+// Circuit states reported by CircuitBreakerLLM.State.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// CircuitBreakerLLM ejects an unhealthy backend: after FailureThreshold
+// consecutive failures it fails fast with ErrCircuitOpen for Cooldown, then
+// lets a single trial request through. A successful trial closes the circuit.
+type CircuitBreakerLLM struct {
+	next LLM
+	cfg  CircuitBreakerConfig
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	trial     bool // a half-open trial request is in flight
+}
+
+// NewCircuitBreakerLLM wraps next with a circuit breaker.
+func NewCircuitBreakerLLM(next LLM, cfg CircuitBreakerConfig) *CircuitBreakerLLM {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = agora.IsRetryable
+	}
+	return &CircuitBreakerLLM{next: next, cfg: cfg}
+}
+
+// State reports whether the circuit is closed, open or half-open.
+func (c *CircuitBreakerLLM) State() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.failures < c.cfg.FailureThreshold:
+		return CircuitClosed
+	case time.Now().Before(c.openUntil):
+		return CircuitOpen
+	default:
+		return CircuitHalfOpen
+	}
+}
+
+// Invoke implements the LLM interface.
+func (c *CircuitBreakerLLM) Invoke(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+	if err := c.allow(); err != nil {
+		return agora.ModelResponse{}, err
+	}
+	resp, err := c.next.Invoke(ctx, request)
+	c.record(err)
+	return resp, err
+}
+
+// Stream implements StreamingLLM.
+func (c *CircuitBreakerLLM) Stream(ctx context.Context, request agora.ModelRequest, onChunk func(chunk agora.ModelResponse) error) (agora.ModelResponse, error) {
+	if err := c.allow(); err != nil {
+		return agora.ModelResponse{}, err
+	}
+	resp, err := Stream(ctx, c.next, request, onChunk)
+	c.record(err)
+	return resp, err
+}
+
+// allow rejects requests while the circuit is open, and lets one trial
+// request through once the cooldown has passed.
+func (c *CircuitBreakerLLM) allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures < c.cfg.FailureThreshold {
+		return nil
+	}
+	if time.Now().Before(c.openUntil) || c.trial {
+		return fmt.Errorf("%w: retry after %s", ErrCircuitOpen, c.openUntil.Format(time.RFC3339))
+	}
+	c.trial = true
+	return nil
+}
+
+// record updates the failure count with the outcome of a request.
+func (c *CircuitBreakerLLM) record(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trial = false
+	if err == nil || !c.cfg.IsFailure(err) {
+		if err == nil {
+			c.failures = 0
+		}
+		return
+	}
+	c.failures++
+	if c.failures >= c.cfg.FailureThreshold {
+		c.openUntil = time.Now().Add(c.cfg.Cooldown)
+	}
+}
@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/amangsingh/agora"
+)
+
+// Embedder turns texts into vectors, one per text and in the same order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Dimensions is the length of the vectors, or 0 while it is unknown.
+	Dimensions() int
+}
+
+// defaultEmbedBatchSize is how many texts are sent per request by default.
+const defaultEmbedBatchSize = 64
+
+// OpenAICompatibleEmbedder calls an OpenAI-style /embeddings endpoint.
+type OpenAICompatibleEmbedder struct {
+	BaseURL   string
+	ModelName string
+	Token     string
+	// BatchSize caps the texts sent per request. Defaults to 64.
+	BatchSize int
+	// RequestDimensions asks models that support it (such as
+	// text-embedding-3) for shorter vectors. Zero uses the model's default.
+	RequestDimensions int
+
+	mu   sync.Mutex
+	dims int // learned from the first response
+}
+
+// NewOpenAICompatibleEmbedder creates a new embedder.
+func NewOpenAICompatibleEmbedder(baseURL, model, token string) *OpenAICompatibleEmbedder {
+	return &OpenAICompatibleEmbedder{
+		BaseURL:   baseURL,
+		ModelName: model,
+		Token:     token,
+		BatchSize: defaultEmbedBatchSize,
+	}
+}
+
+type embeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Dimensions implements Embedder. Before the first call it reports
+// RequestDimensions, or 0 if that is unset.
+func (e *OpenAICompatibleEmbedder) Dimensions() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.dims == 0 {
+		return e.RequestDimensions
+	}
+	return e.dims
+}
+
+// Embed implements Embedder, splitting texts into batches of BatchSize.
+func (e *OpenAICompatibleEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	batchSize := e.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEmbedBatchSize
+	}
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		batch, err := e.embedBatch(ctx, texts[start:min(start+batchSize, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (e *OpenAICompatibleEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	// 1. Build and send the request.
+	payloadBytes, err := json.Marshal(embeddingRequest{Model: e.ModelName, Input: texts, Dimensions: e.RequestDimensions})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", e.BaseURL+"/embeddings", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", e.Token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("trouble executing embedding call: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("trouble executing embedding call: %w", &agora.StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)})
+	}
+
+	// 2. Decode, restoring input order since providers may reorder.
+	var decoded embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(decoded.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(decoded.Data))
+	}
+	sort.Slice(decoded.Data, func(i, j int) bool {
+		return decoded.Data[i].Index < decoded.Data[j].Index
+	})
+
+	// 3. Every vector must have the same length.
+	vectors := make([][]float32, len(decoded.Data))
+	for i, d := range decoded.Data {
+		if err := e.checkDimensions(len(d.Embedding)); err != nil {
+			return nil, err
+		}
+		vectors[i] = d.Embedding
+	}
+	return vectors, nil
+}
+
+func (e *OpenAICompatibleEmbedder) checkDimensions(n int) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.dims == 0 {
+		e.dims = n
+	}
+	if n != e.dims {
+		return fmt.Errorf("embedding has %d dimensions, expected %d", n, e.dims)
+	}
+	return nil
+}
+
+// OllamaEmbedder is an Embedder for Ollama, through its OpenAI-compatible endpoint.
+type OllamaEmbedder struct {
+	Client *OpenAICompatibleEmbedder
+}
+
+// NewOllamaEmbedder creates a new Ollama embedder.
+// It defaults to "http://localhost:11434/v1" if base URL is not provided.
+// It reads the model name either from the arg or ENV "OLLAMA_EMBED_MODEL".
+func NewOllamaEmbedder(baseURL string, model string) *OllamaEmbedder {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434/v1"
+	}
+	if model == "" {
+		model = os.Getenv("OLLAMA_EMBED_MODEL")
+		if model == "" {
+			model = "nomic-embed-text" // Sane default
+		}
+	}
+	return &OllamaEmbedder{
+		Client: NewOpenAICompatibleEmbedder(baseURL, model, "ollama"),
+	}
+}
+
+// Embed implements Embedder.
+func (e *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.Client.Embed(ctx, texts)
+}
+
+// Dimensions implements Embedder.
+func (e *OllamaEmbedder) Dimensions() int {
+	return e.Client.Dimensions()
+}
@@ -2,7 +2,11 @@ package llm
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strings"
 
 	"github.com/amangsingh/agora"
 )
@@ -33,5 +37,92 @@ func NewOllamaLLM(baseURL string, model string) *OllamaLLM {
 
 // Invoke implements the LLM interface.
 func (l *OllamaLLM) Invoke(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+	request, err := ollamaRequest(ctx, request)
+	if err != nil {
+		return agora.ModelResponse{}, err
+	}
 	return l.Client.Invoke(ctx, request)
 }
+
+// Stream implements StreamingLLM.
+func (l *OllamaLLM) Stream(ctx context.Context, request agora.ModelRequest, onChunk func(chunk agora.ModelResponse) error) (agora.ModelResponse, error) {
+	request, err := ollamaRequest(ctx, request)
+	if err != nil {
+		return agora.ModelResponse{}, err
+	}
+	return l.Client.Stream(ctx, request, onChunk)
+}
+
+// maxOllamaImageBytes bounds the images downloaded for Ollama.
+const maxOllamaImageBytes = 20 << 20
+
+// ollamaRequest maps content parts to what Ollama's OpenAI-compatible API
+// understands: images must be inline, so remote ones are downloaded, and
+// files are not supported, so text files become text parts.
+func ollamaRequest(ctx context.Context, request agora.ModelRequest) (agora.ModelRequest, error) {
+	var messages []agora.ChatMessage
+	for i, msg := range request.Messages {
+		if len(msg.Parts) == 0 {
+			continue
+		}
+		if messages == nil {
+			messages = append([]agora.ChatMessage(nil), request.Messages...)
+		}
+		parts := make([]agora.ContentPart, len(msg.Parts))
+		for j, part := range msg.Parts {
+			switch {
+			case part.Type == agora.PartImage && len(part.Data) == 0:
+				mimeType, data, err := downloadImage(ctx, part.URL)
+				if err != nil {
+					return request, err
+				}
+				part = agora.ImageDataPart(mimeType, data)
+			case part.Type == agora.PartFile:
+				if part.FileID != "" || !isTextMIME(part.MIMEType) {
+					return request, fmt.Errorf("ollama does not support file parts, except inline text files (got %q)", part.Filename)
+				}
+				part = agora.TextPart(part.Filename + ":\n" + string(part.Data))
+			}
+			parts[j] = part
+		}
+		messages[i].Parts = parts
+	}
+	if messages != nil {
+		request.Messages = messages
+	}
+	return request, nil
+}
+
+// downloadImage fetches an image to send inline.
+func downloadImage(ctx context.Context, url string) (string, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create image request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to download image %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("failed to download image %s: status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOllamaImageBytes+1))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to download image %s: %w", url, err)
+	}
+	if len(data) > maxOllamaImageBytes {
+		return "", nil, fmt.Errorf("image %s is larger than %d bytes", url, maxOllamaImageBytes)
+	}
+	mimeType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = http.DetectContentType(data)
+	}
+	return mimeType, data, nil
+}
+
+// isTextMIME reports whether a file of this type can be sent as text.
+func isTextMIME(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	return strings.HasPrefix(mimeType, "text/") || mimeType == "application/json" || mimeType == "application/xml" || mimeType == "application/x-yaml"
+}
@@ -8,7 +8,9 @@ import (
 )
 
 // OpenAICompatibleLLM is a concrete implementation of the LLM interface
-// for any backend that mimics the OpenAI Chat Completions API.
+// for any backend that mimics the OpenAI Chat Completions API. Content parts
+// are sent as Chat Completions content arrays: images as image_url (inline
+// ones as data: URLs) and files as file parts.
 type OpenAICompatibleLLM struct {
 	BaseURL   string
 	ModelName string
@@ -0,0 +1,260 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/amangsingh/agora"
+)
+
+// RateLimitConfig configures a RateLimiter. Zero limits are unlimited.
+type RateLimitConfig struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+	// Interval is the window the limits apply to. Defaults to one minute;
+	// set it for providers that limit per second.
+	Interval time.Duration
+}
+
+// RateLimiter paces requests against request and token limits. Both refill
+// continuously, so a full minute's allowance is available as a burst. Waiters
+// are served in arrival order, so a large request is not starved by a stream
+// of small ones. A RateLimiter is safe for concurrent use; share one between
+// every RateLimitedLLM that talks to the same provider account.
+type RateLimiter struct {
+	cfg RateLimitConfig
+
+	mu       sync.Mutex
+	requests float64 // available requests
+	tokens   float64 // available tokens, negative after an underestimate
+	last     time.Time
+	queue    *list.List // of *rateWaiter, front is served next
+}
+
+type rateWaiter struct {
+	tokens float64
+	wake   chan struct{}
+}
+
+// NewRateLimiter creates a limiter with its full allowance available.
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	return &RateLimiter{
+		cfg:      cfg,
+		requests: float64(cfg.RequestsPerMinute),
+		tokens:   float64(cfg.TokensPerMinute),
+		last:     time.Now(),
+		queue:    list.New(),
+	}
+}
+
+var (
+	sharedLimitersMu sync.Mutex
+	sharedLimiters   = map[string]*RateLimiter{}
+)
+
+// SharedRateLimiter returns the process-wide limiter registered under name,
+// creating it with cfg on first use. Later calls ignore cfg.
+func SharedRateLimiter(name string, cfg RateLimitConfig) *RateLimiter {
+	sharedLimitersMu.Lock()
+	defer sharedLimitersMu.Unlock()
+	if l, ok := sharedLimiters[name]; ok {
+		return l
+	}
+	l := NewRateLimiter(cfg)
+	sharedLimiters[name] = l
+	return l
+}
+
+// Wait blocks until one request using tokens can be made, or ctx is done.
+// Requests estimated above the whole token allowance wait for a full bucket.
+func (r *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	w := &rateWaiter{tokens: float64(tokens), wake: make(chan struct{}, 1)}
+	if r.cfg.TokensPerMinute > 0 {
+		w.tokens = min(w.tokens, float64(r.cfg.TokensPerMinute))
+	}
+
+	r.mu.Lock()
+	el := r.queue.PushBack(w)
+	for {
+		var delay time.Duration
+		if r.queue.Front() == el {
+			r.refill()
+			delay = r.delay(w)
+			if delay == 0 {
+				if r.cfg.RequestsPerMinute > 0 {
+					r.requests--
+				}
+				if r.cfg.TokensPerMinute > 0 {
+					r.tokens -= w.tokens
+				}
+				r.queue.Remove(el)
+				r.wakeFront()
+				r.mu.Unlock()
+				return nil
+			}
+		}
+		r.mu.Unlock()
+
+		// Only the front waiter sleeps on a timer; the rest wait their turn.
+		var timer *time.Timer
+		var fired <-chan time.Time
+		if delay > 0 {
+			timer = time.NewTimer(delay)
+			fired = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			r.mu.Lock()
+			r.queue.Remove(el)
+			r.wakeFront()
+			r.mu.Unlock()
+			return ctx.Err()
+		case <-fired:
+		case <-w.wake:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		r.mu.Lock()
+	}
+}
+
+// Adjust corrects the token count once the real usage of a request is known:
+// a positive delta charges tokens that were underestimated, a negative one
+// refunds an overestimate.
+func (r *RateLimiter) Adjust(delta int) {
+	if r.cfg.TokensPerMinute <= 0 || delta == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refill()
+	r.tokens = min(r.tokens-float64(delta), float64(r.cfg.TokensPerMinute))
+	r.wakeFront()
+}
+
+// refill adds the allowance accrued since the last refill.
+func (r *RateLimiter) refill() {
+	now := time.Now()
+	elapsed := float64(now.Sub(r.last)) / float64(r.cfg.Interval)
+	r.last = now
+	if limit := float64(r.cfg.RequestsPerMinute); limit > 0 {
+		r.requests = min(r.requests+elapsed*limit, limit)
+	}
+	if limit := float64(r.cfg.TokensPerMinute); limit > 0 {
+		r.tokens = min(r.tokens+elapsed*limit, limit)
+	}
+}
+
+// delay is how long until w can be served, or zero if it can be now.
+func (r *RateLimiter) delay(w *rateWaiter) time.Duration {
+	var delay time.Duration
+	if limit := float64(r.cfg.RequestsPerMinute); limit > 0 && r.requests < 1 {
+		delay = max(delay, time.Duration((1-r.requests)/limit*float64(r.cfg.Interval)))
+	}
+	if limit := float64(r.cfg.TokensPerMinute); limit > 0 && r.tokens < w.tokens {
+		delay = max(delay, time.Duration((w.tokens-r.tokens)/limit*float64(r.cfg.Interval)))
+	}
+	if delay > 0 && delay < time.Millisecond {
+		delay = time.Millisecond
+	}
+	return delay
+}
+
+// wakeFront lets the waiter at the front of the queue re-check the allowance.
+func (r *RateLimiter) wakeFront() {
+	if front := r.queue.Front(); front != nil {
+		select {
+		case front.Value.(*rateWaiter).wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// RateLimitedLLM is an LLM decorator that waits for its RateLimiter before
+// every call. Tokens are charged from an estimate up front and reconciled
+// with the reported usage afterwards.
+type RateLimitedLLM struct {
+	next    LLM
+	limiter *RateLimiter
+	// Estimate predicts the tokens a request will use. Defaults to EstimateTokens.
+	Estimate func(request agora.ModelRequest) int
+}
+
+// NewRateLimitedLLM wraps next so its calls go through limiter.
+func NewRateLimitedLLM(next LLM, limiter *RateLimiter) *RateLimitedLLM {
+	return &RateLimitedLLM{next: next, limiter: limiter, Estimate: EstimateTokens}
+}
+
+// Invoke implements the LLM interface.
+func (r *RateLimitedLLM) Invoke(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+	estimate, err := r.wait(ctx, request)
+	if err != nil {
+		return agora.ModelResponse{}, err
+	}
+	resp, err := r.next.Invoke(ctx, request)
+	r.reconcile(estimate, resp, err)
+	return resp, err
+}
+
+// Stream implements StreamingLLM.
+func (r *RateLimitedLLM) Stream(ctx context.Context, request agora.ModelRequest, onChunk func(chunk agora.ModelResponse) error) (agora.ModelResponse, error) {
+	estimate, err := r.wait(ctx, request)
+	if err != nil {
+		return agora.ModelResponse{}, err
+	}
+	resp, err := Stream(ctx, r.next, request, onChunk)
+	r.reconcile(estimate, resp, err)
+	return resp, err
+}
+
+func (r *RateLimitedLLM) wait(ctx context.Context, request agora.ModelRequest) (int, error) {
+	estimate := r.Estimate
+	if estimate == nil {
+		estimate = EstimateTokens
+	}
+	tokens := estimate(request)
+	return tokens, r.limiter.Wait(ctx, tokens)
+}
+
+// reconcile replaces the estimate with the reported usage. Failed calls and
+// providers that report no usage keep the estimate.
+func (r *RateLimitedLLM) reconcile(estimate int, resp agora.ModelResponse, err error) {
+	if err == nil && resp.Usage.TotalTokens > 0 {
+		r.limiter.Adjust(resp.Usage.TotalTokens - estimate)
+	}
+}
+
+// mediaTokenEstimate is what an image or file part is assumed to cost.
+const mediaTokenEstimate = 1000
+
+// EstimateTokens roughly predicts the tokens of a request: four characters
+// per prompt token, mediaTokenEstimate per image or file, plus MaxTokens for
+// the completion when it is set.
+func EstimateTokens(request agora.ModelRequest) int {
+	chars := 0
+	for _, msg := range request.Messages {
+		chars += len(msg.Role) + len(msg.Text())
+		for _, part := range msg.Parts {
+			if part.Type != agora.PartText {
+				chars += 4 * mediaTokenEstimate
+			}
+		}
+		for _, call := range msg.ToolCalls {
+			chars += len(call.Function.Name)
+			for k, v := range call.Function.Arguments {
+				chars += len(k) + len(fmt.Sprint(v))
+			}
+		}
+	}
+	for _, tool := range request.Tools {
+		chars += len(tool.Function.Name) + len(tool.Function.Description)
+	}
+	return chars/4 + 1 + request.MaxTokens
+}
@@ -0,0 +1,152 @@
+package llm
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/redact"
+)
+
+// RedactingLLM is an LLM decorator that keeps personal data and secrets away
+// from the model. Every request is masked with numbered placeholders, e.g.
+// "[EMAIL_1]", and placeholders in the reply, tool call arguments included,
+// are turned back into the originals, so the caller never sees them.
+type RedactingLLM struct {
+	next     LLM
+	redactor *redact.Redactor
+}
+
+// NewRedactingLLM wraps next so its requests are masked by redactor.
+func NewRedactingLLM(next LLM, redactor *redact.Redactor) *RedactingLLM {
+	return &RedactingLLM{next: next, redactor: redactor}
+}
+
+// Invoke implements the LLM interface.
+func (r *RedactingLLM) Invoke(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+	vault := redact.NewVault()
+	resp, err := r.next.Invoke(ctx, r.mask(request, vault))
+	if err != nil {
+		return resp, err
+	}
+	for i := range resp.Choices {
+		resp.Choices[i].Message = restoreMessage(resp.Choices[i].Message, vault)
+	}
+	return resp, nil
+}
+
+// Stream implements StreamingLLM. Text that may be the start of a placeholder
+// is held back until the placeholder is complete, so chunks never show one
+// half restored.
+func (r *RedactingLLM) Stream(ctx context.Context, request agora.ModelRequest, onChunk func(chunk agora.ModelResponse) error) (agora.ModelResponse, error) {
+	vault := redact.NewVault()
+	pending := map[int]string{}
+	resp, err := Stream(ctx, r.next, r.mask(request, vault), func(chunk agora.ModelResponse) error {
+		for i := range chunk.Choices {
+			c := &chunk.Choices[i]
+			text := pending[c.Index] + c.Delta.Content
+			if c.FinishReason == "" {
+				text, pending[c.Index] = splitPartialPlaceholder(text)
+			} else {
+				delete(pending, c.Index)
+			}
+			c.Delta.Content = text
+			c.Delta = restoreMessage(c.Delta, vault)
+		}
+		return onChunk(chunk)
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	// Deliver whatever a stream without finish reasons left held back.
+	for index, text := range pending {
+		if text == "" {
+			continue
+		}
+		chunk := agora.ModelResponse{Model: resp.Model, Choices: []agora.Choice{{Index: index, Delta: agora.ChatMessage{Content: vault.Restore(text)}}}}
+		if err := onChunk(chunk); err != nil {
+			return agora.ModelResponse{}, err
+		}
+	}
+	for i := range resp.Choices {
+		resp.Choices[i].Message = restoreMessage(resp.Choices[i].Message, vault)
+	}
+	return resp, nil
+}
+
+// mask returns a copy of the request with every message masked.
+func (r *RedactingLLM) mask(request agora.ModelRequest, vault *redact.Vault) agora.ModelRequest {
+	maskText := func(s string) string { return r.redactor.Mask(s, vault) }
+	messages := make([]agora.ChatMessage, len(request.Messages))
+	for i, msg := range request.Messages {
+		messages[i] = mapMessage(msg, maskText)
+	}
+	request.Messages = messages
+	return request
+}
+
+func restoreMessage(msg agora.ChatMessage, vault *redact.Vault) agora.ChatMessage {
+	return mapMessage(msg, vault.Restore)
+}
+
+// mapMessage applies f to the text of a message, its text parts and its tool
+// call arguments. Images and files are passed through.
+func mapMessage(msg agora.ChatMessage, f func(string) string) agora.ChatMessage {
+	msg.Content = f(msg.Content)
+	msg.ReasoningContent = f(msg.ReasoningContent)
+	if len(msg.Parts) > 0 {
+		parts := make([]agora.ContentPart, len(msg.Parts))
+		for i, part := range msg.Parts {
+			part.Text = f(part.Text)
+			parts[i] = part
+		}
+		msg.Parts = parts
+	}
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]agora.ToolCall, len(msg.ToolCalls))
+		for i, call := range msg.ToolCalls {
+			if call.Function.Arguments != nil {
+				call.Function.Arguments = mapStrings(call.Function.Arguments, f).(map[string]interface{})
+			}
+			calls[i] = call
+		}
+		msg.ToolCalls = calls
+	}
+	return msg
+}
+
+// mapStrings copies decoded JSON, applying f to every string in it.
+func mapStrings(v any, f func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return f(v)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = mapStrings(item, f)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = mapStrings(item, f)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+var partialPlaceholder = regexp.MustCompile(`\[[A-Za-z0-9_]{0,63}$`)
+
+// splitPartialPlaceholder splits off a trailing "[" and what follows it if it
+// could still become a placeholder.
+func splitPartialPlaceholder(text string) (string, string) {
+	i := strings.LastIndexByte(text, '[')
+	if i < 0 || !partialPlaceholder.MatchString(text[i:]) {
+		return text, ""
+	}
+	return text[:i], text[i:]
+}
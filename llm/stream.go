@@ -0,0 +1,272 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/amangsingh/agora"
+)
+
+// StreamingLLM is an LLM that can also deliver its response incrementally.
+// onChunk receives every chunk as it arrives, with the new text in
+// Choices[i].Delta; Stream returns the assembled response, shaped exactly
+// like the result of Invoke.
+type StreamingLLM interface {
+	LLM
+	Stream(ctx context.Context, request agora.ModelRequest, onChunk func(chunk agora.ModelResponse) error) (agora.ModelResponse, error)
+}
+
+// Stream streams from l if it supports streaming. Otherwise it invokes l and
+// delivers the whole answer as a single chunk.
+func Stream(ctx context.Context, l LLM, request agora.ModelRequest, onChunk func(chunk agora.ModelResponse) error) (agora.ModelResponse, error) {
+	if s, ok := l.(StreamingLLM); ok {
+		return s.Stream(ctx, request, onChunk)
+	}
+
+	response, err := l.Invoke(ctx, request)
+	if err != nil {
+		return agora.ModelResponse{}, err
+	}
+	chunk := response
+	chunk.Choices = make([]agora.Choice, len(response.Choices))
+	for i, c := range response.Choices {
+		chunk.Choices[i] = agora.Choice{Index: c.Index, FinishReason: c.FinishReason, Delta: c.Message}
+	}
+	if err := onChunk(chunk); err != nil {
+		return agora.ModelResponse{}, err
+	}
+	return response, nil
+}
+
+// Stream implements StreamingLLM using server-sent events from the Chat
+// Completions API.
+func (l *OpenAICompatibleLLM) Stream(ctx context.Context, request agora.ModelRequest, onChunk func(chunk agora.ModelResponse) error) (agora.ModelResponse, error) {
+	// 1. Build the streaming request.
+	request.Model = l.ModelName
+	request.Stream = true
+	payloadBytes, err := json.Marshal(request)
+	if err != nil {
+		return agora.ModelResponse{}, fmt.Errorf("failed to parse payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", l.BaseURL+"/chat/completions", bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return agora.ModelResponse{}, fmt.Errorf("failed to create http request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", l.Token)
+
+	// 2. Run it.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return agora.ModelResponse{}, fmt.Errorf("trouble executing model call: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return agora.ModelResponse{}, fmt.Errorf("trouble executing model call: %w", &agora.StatusError{StatusCode: resp.StatusCode, Body: string(bodyBytes)})
+	}
+
+	// 3. Forward every event and assemble the final response.
+	var acc streamAccumulator
+	err = readEvents(resp.Body, func(data []byte) error {
+		var chunk streamChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		acc.add(chunk)
+		return onChunk(chunk.toResponse())
+	})
+	if err != nil {
+		return agora.ModelResponse{}, err
+	}
+	return acc.response()
+}
+
+// readEvents calls onData with the payload of every "data:" line of an SSE
+// stream until the stream ends or sends [DONE].
+func readEvents(r io.Reader, onData func(data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // comments, event names and keep-alive blank lines
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			return nil
+		}
+		if data == "" {
+			continue
+		}
+		if err := onData([]byte(data)); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stream: %w", err)
+	}
+	return nil
+}
+
+// streamChunk is one streamed event. Tool call arguments arrive as string
+// fragments, so they cannot be decoded into agora.ToolCall directly.
+type streamChunk struct {
+	ID                string `json:"id"`
+	Object            string `json:"object"`
+	Created           int    `json:"created"`
+	Model             string `json:"model"`
+	SystemFingerprint string `json:"system_fingerprint"`
+	Choices           []struct {
+		Index        int    `json:"index"`
+		FinishReason string `json:"finish_reason"`
+		Delta        struct {
+			Role             string `json:"role"`
+			Content          string `json:"content"`
+			ReasoningContent string `json:"reasoning_content"`
+			ToolCalls        []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *agora.Usage `json:"usage"`
+}
+
+// toResponse converts the chunk for onChunk. Partial tool calls are left out;
+// they are only complete in the assembled response.
+func (c streamChunk) toResponse() agora.ModelResponse {
+	resp := agora.ModelResponse{
+		Id:                c.ID,
+		Object:            c.Object,
+		Created:           c.Created,
+		Model:             c.Model,
+		SystemFingerprint: c.SystemFingerprint,
+	}
+	if c.Usage != nil {
+		resp.Usage = *c.Usage
+	}
+	for _, choice := range c.Choices {
+		resp.Choices = append(resp.Choices, agora.Choice{
+			Index:        choice.Index,
+			FinishReason: choice.FinishReason,
+			Delta: agora.ChatMessage{
+				Role:             choice.Delta.Role,
+				Content:          choice.Delta.Content,
+				ReasoningContent: choice.Delta.ReasoningContent,
+			},
+		})
+	}
+	return resp
+}
+
+// streamAccumulator assembles streamed chunks into a complete response.
+type streamAccumulator struct {
+	meta    agora.ModelResponse
+	choices map[int]*choiceAccumulator
+}
+
+type choiceAccumulator struct {
+	role, finishReason string
+	content, reasoning strings.Builder
+	toolCalls          map[int]*toolCallAccumulator
+}
+
+type toolCallAccumulator struct {
+	id, typ, name string
+	arguments     strings.Builder
+}
+
+func (a *streamAccumulator) add(chunk streamChunk) {
+	if a.choices == nil {
+		a.choices = make(map[int]*choiceAccumulator)
+	}
+	if chunk.ID != "" {
+		a.meta.Id, a.meta.Object, a.meta.Created = chunk.ID, chunk.Object, chunk.Created
+		a.meta.Model, a.meta.SystemFingerprint = chunk.Model, chunk.SystemFingerprint
+	}
+	if chunk.Usage != nil {
+		a.meta.Usage = *chunk.Usage
+	}
+
+	for _, c := range chunk.Choices {
+		choice, ok := a.choices[c.Index]
+		if !ok {
+			choice = &choiceAccumulator{toolCalls: make(map[int]*toolCallAccumulator)}
+			a.choices[c.Index] = choice
+		}
+		if c.Delta.Role != "" {
+			choice.role = c.Delta.Role
+		}
+		if c.FinishReason != "" {
+			choice.finishReason = c.FinishReason
+		}
+		choice.content.WriteString(c.Delta.Content)
+		choice.reasoning.WriteString(c.Delta.ReasoningContent)
+
+		for _, tc := range c.Delta.ToolCalls {
+			call, ok := choice.toolCalls[tc.Index]
+			if !ok {
+				call = &toolCallAccumulator{}
+				choice.toolCalls[tc.Index] = call
+			}
+			if tc.ID != "" {
+				call.id = tc.ID
+			}
+			if tc.Type != "" {
+				call.typ = tc.Type
+			}
+			call.name += tc.Function.Name
+			call.arguments.WriteString(tc.Function.Arguments)
+		}
+	}
+}
+
+func (a *streamAccumulator) response() (agora.ModelResponse, error) {
+	resp := a.meta
+	for _, index := range sortedIndexes(a.choices) {
+		choice := a.choices[index]
+		msg := agora.ChatMessage{
+			Role:             choice.role,
+			Content:          choice.content.String(),
+			ReasoningContent: choice.reasoning.String(),
+		}
+		for _, i := range sortedIndexes(choice.toolCalls) {
+			tc := choice.toolCalls[i]
+			var call agora.ToolCall
+			call.ID, call.Type, call.Function.Name = tc.id, tc.typ, tc.name
+			if args := tc.arguments.String(); args != "" {
+				if err := json.Unmarshal([]byte(args), &call.Function.Arguments); err != nil {
+					return agora.ModelResponse{}, fmt.Errorf("invalid arguments for tool call '%s': %w", tc.name, err)
+				}
+			}
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		resp.Choices = append(resp.Choices, agora.Choice{Index: index, FinishReason: choice.finishReason, Message: msg})
+	}
+	return resp, nil
+}
+
+func sortedIndexes[V any](m map[int]V) []int {
+	indexes := make([]int, 0, len(m))
+	for i := range m {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
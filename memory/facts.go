@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+	"github.com/amangsingh/agora/vectorstore"
+)
+
+// Fact is something the agent chose to remember.
+type Fact struct {
+	ID           string  `json:"id"`
+	Text         string  `json:"text"`
+	RememberedAt string  `json:"remembered_at,omitempty"`
+	Score        float64 `json:"score,omitempty"`
+}
+
+// Facts is a semantic memory of short statements, such as a user's
+// preferences, searched by meaning rather than by words. Facts live in a
+// vector store and are scoped, usually to a user, so one store can serve
+// many of them.
+type Facts struct {
+	Embedder llm.Embedder
+	Store    vectorstore.VectorStore
+	// Scope keeps these facts apart from other scopes in the same store.
+	Scope string
+}
+
+// NewFacts creates a fact memory for one scope.
+func NewFacts(embedder llm.Embedder, store vectorstore.VectorStore, scope string) *Facts {
+	return &Facts{Embedder: embedder, Store: store, Scope: scope}
+}
+
+// Remember stores a fact and returns its ID. Remembering the same text twice
+// keeps one copy.
+func (f *Facts) Remember(ctx context.Context, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("fact is empty")
+	}
+	vectors, err := f.Embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return "", fmt.Errorf("failed to embed fact: %w", err)
+	}
+	if len(vectors) != 1 {
+		return "", fmt.Errorf("expected 1 embedding, got %d", len(vectors))
+	}
+
+	sum := sha256.Sum256([]byte(f.Scope + "\x00" + strings.ToLower(text)))
+	id := "fact:" + hex.EncodeToString(sum[:8])
+	doc := vectorstore.Document{
+		ID:      id,
+		Content: text,
+		Metadata: map[string]string{
+			"kind":          "fact",
+			"scope":         f.Scope,
+			"remembered_at": time.Now().UTC().Format(time.RFC3339),
+		},
+		Vector: vectors[0],
+	}
+	if err := f.Store.Upsert(ctx, doc); err != nil {
+		return "", fmt.Errorf("failed to store fact: %w", err)
+	}
+	return id, nil
+}
+
+// Search returns the k facts closest in meaning to the query, best first.
+func (f *Facts) Search(ctx context.Context, query string, k int) ([]Fact, error) {
+	vectors, err := f.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("expected 1 embedding, got %d", len(vectors))
+	}
+	results, err := f.Store.Search(ctx, vectors[0], k, vectorstore.Filter{"kind": "fact", "scope": f.Scope})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search facts: %w", err)
+	}
+	facts := make([]Fact, len(results))
+	for i, r := range results {
+		facts[i] = Fact{ID: r.ID, Text: r.Content, RememberedAt: r.Metadata["remembered_at"], Score: r.Score}
+	}
+	return facts, nil
+}
+
+// Forget removes facts by ID.
+func (f *Facts) Forget(ctx context.Context, ids ...string) error {
+	return f.Store.Delete(ctx, ids...)
+}
+
+// Tools returns the remember_fact and search_facts tools, letting an agent
+// manage its own memory. Register them with an agora.ToolRegistry.
+func (f *Facts) Tools() []agora.Tool {
+	return []agora.Tool{rememberTool{f}, searchTool{f}}
+}
+
+type rememberTool struct{ facts *Facts }
+
+func (t rememberTool) Definition() agora.ToolDefinition {
+	return agora.ToolDefinition{
+		Type: "function",
+		Function: agora.Function{
+			Name:        "remember_fact",
+			Description: "Save a short, self-contained fact worth knowing in future conversations, such as a preference or detail the user shared.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"fact": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"fact"},
+			},
+		},
+	}
+}
+
+func (t rememberTool) Execute(ctx context.Context, args map[string]interface{}) (any, error) {
+	text, _ := args["fact"].(string)
+	id, err := t.facts.Remember(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"id": id, "remembered": true}, nil
+}
+
+type searchTool struct{ facts *Facts }
+
+func (t searchTool) Definition() agora.ToolDefinition {
+	return agora.ToolDefinition{
+		Type: "function",
+		Function: agora.Function{
+			Name:        "search_facts",
+			Description: "Search the facts remembered in earlier conversations.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{"type": "string"},
+					"limit": map[string]interface{}{"type": "integer", "description": "Maximum number of facts. Defaults to 5."},
+				},
+				"required": []string{"query"},
+			},
+		},
+	}
+}
+
+func (t searchTool) Execute(ctx context.Context, args map[string]interface{}) (any, error) {
+	query, _ := args["query"].(string)
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query is empty")
+	}
+	// JSON numbers arrive as float64.
+	limit := 5
+	if n, ok := args["limit"].(float64); ok && n >= 1 {
+		limit = int(n)
+	}
+	facts, err := t.facts.Search(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"facts": facts}, nil
+}
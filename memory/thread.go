@@ -0,0 +1,127 @@
+// Package memory gives agents memory beyond a single execution: the chat
+// history of a conversation thread, and semantic facts the agent writes and
+// searches through tools.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/amangsingh/agora"
+)
+
+// ThreadStore persists the chat history of conversation threads.
+// pkg/storage provides a SQLite implementation; MemoryThreadStore is an
+// in-process one.
+type ThreadStore interface {
+	// LoadThread returns the last limit messages of a thread, oldest first.
+	// A limit of zero or less returns them all; an unknown thread has none.
+	LoadThread(ctx context.Context, threadID string, limit int) ([]agora.ChatMessage, error)
+	// AppendThread adds messages to the end of a thread, creating it if needed.
+	AppendThread(ctx context.Context, threadID string, messages ...agora.ChatMessage) error
+}
+
+// Thread continues a conversation across executions. State loads the
+// thread's history into a fresh ConversationState and Save persists the
+// turns the execution added to it.
+type Thread struct {
+	ID    string
+	Store ThreadStore
+	// MaxHistory caps how many past messages are loaded. Zero loads them all.
+	MaxHistory int
+
+	loaded int
+}
+
+// NewThread creates a Thread loading its whole history.
+func NewThread(store ThreadStore, id string) *Thread {
+	return &Thread{ID: id, Store: store}
+}
+
+// State returns a ConversationState holding the thread's history and the new
+// input. The thread ID is saved under "thread_id".
+func (t *Thread) State(ctx context.Context, input string) (*agora.ConversationState, error) {
+	history, err := t.Store.LoadThread(ctx, t.ID, t.MaxHistory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load thread %s: %w", t.ID, err)
+	}
+	// A capped history may start with tool results whose call was cut off,
+	// which providers reject.
+	for len(history) > 0 && history[0].Role == "tool" {
+		history = history[1:]
+	}
+	t.loaded = len(history)
+
+	s := &agora.ConversationState{
+		BaseState: agora.NewBaseState(),
+		History:   history,
+		Input:     input,
+	}
+	s.Set("thread_id", t.ID)
+	return s, nil
+}
+
+// Save appends the messages added to the state since State loaded it.
+func (t *Thread) Save(ctx context.Context, s *agora.ConversationState) error {
+	if len(s.History) <= t.loaded {
+		return nil
+	}
+	if err := t.Store.AppendThread(ctx, t.ID, s.History[t.loaded:]...); err != nil {
+		return fmt.Errorf("failed to save thread %s: %w", t.ID, err)
+	}
+	t.loaded = len(s.History)
+	return nil
+}
+
+// Run executes the graph on the next input of the thread and saves the new
+// turns. Nothing is saved if the execution fails.
+func (t *Thread) Run(ctx context.Context, g *agora.Graph, input string) (*agora.ConversationState, error) {
+	s, err := t.State(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	final, err := g.Execute(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	fs, ok := final.(*agora.ConversationState)
+	if !ok {
+		return nil, fmt.Errorf("graph returned %T, expected *agora.ConversationState", final)
+	}
+	if err := t.Save(ctx, fs); err != nil {
+		return fs, err
+	}
+	return fs, nil
+}
+
+// MemoryThreadStore is a ThreadStore held in memory, for tests and
+// short-lived processes.
+type MemoryThreadStore struct {
+	mu      sync.RWMutex
+	threads map[string][]agora.ChatMessage
+}
+
+// NewMemoryThreadStore creates an empty store.
+func NewMemoryThreadStore() *MemoryThreadStore {
+	return &MemoryThreadStore{threads: make(map[string][]agora.ChatMessage)}
+}
+
+// LoadThread implements ThreadStore.
+func (m *MemoryThreadStore) LoadThread(ctx context.Context, threadID string, limit int) ([]agora.ChatMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	messages := m.threads[threadID]
+	if limit > 0 && len(messages) > limit {
+		messages = messages[len(messages)-limit:]
+	}
+	return append([]agora.ChatMessage(nil), messages...), nil
+}
+
+// AppendThread implements ThreadStore.
+func (m *MemoryThreadStore) AppendThread(ctx context.Context, threadID string, messages ...agora.ChatMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.threads[threadID] = append(m.threads[threadID], messages...)
+	return nil
+}
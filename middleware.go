@@ -0,0 +1,140 @@
+// in agora/middleware.go
+
+package agora
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps a node with cross-cutting behavior. name is the node's name
+// within its graph; NodePath(ctx) gives the full namespaced path at run time.
+type Middleware func(name string, next NodeFunc) NodeFunc
+
+// Use registers middleware applied to every node of the graph. The first
+// middleware registered is the outermost. Middleware runs inside the node's
+// retry and timeout policy, so it sees every attempt.
+func (g *Graph) Use(middleware ...Middleware) {
+	g.Middleware = append(g.Middleware, middleware...)
+}
+
+type middlewareKey struct{}
+
+// inheritedMiddleware returns the middleware enclosing graphs passed down to
+// the graph executing in ctx.
+func inheritedMiddleware(ctx context.Context) []Middleware {
+	mw, _ := ctx.Value(middlewareKey{}).([]Middleware)
+	return mw
+}
+
+// middlewareContext passes the middleware nested graphs should inherit down
+// to the nodes of g.
+func (g *Graph) middlewareContext(ctx context.Context) context.Context {
+	if !g.InheritMiddleware || len(g.Middleware) == 0 {
+		return ctx
+	}
+	inherited := inheritedMiddleware(ctx)
+	chain := append(inherited[:len(inherited):len(inherited)], g.Middleware...)
+	return context.WithValue(ctx, middlewareKey{}, chain)
+}
+
+// wrapNode applies inherited middleware, then the graph's own, to a node.
+func (g *Graph) wrapNode(ctx context.Context, name string, node NodeFunc) NodeFunc {
+	inherited := inheritedMiddleware(ctx)
+	for i := len(g.Middleware) - 1; i >= 0; i-- {
+		node = g.Middleware[i](name, node)
+	}
+	for i := len(inherited) - 1; i >= 0; i-- {
+		node = inherited[i](name, node)
+	}
+	return node
+}
+
+// PanicError is returned by the Recover middleware when a node panics.
+type PanicError struct {
+	Node  string
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("node %s panicked: %v", e.Node, e.Value)
+}
+
+// Recover turns a panicking node into a *PanicError so the graph's error
+// handling (retries, fallbacks, NodeError) applies to it.
+func Recover() Middleware {
+	return func(name string, next NodeFunc) NodeFunc {
+		return func(ctx context.Context, s State) (result NodeResult, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					result = NodeResult{State: s}
+					err = &PanicError{Node: name, Value: r, Stack: debug.Stack()}
+				}
+			}()
+			return next(ctx, s)
+		}
+	}
+}
+
+// Logging logs every node execution with its path, duration and outcome. A
+// nil logger uses slog.Default().
+func Logging(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(name string, next NodeFunc) NodeFunc {
+		return func(ctx context.Context, s State) (NodeResult, error) {
+			path := NodePath(ctx)
+			logger.DebugContext(ctx, "node started", "node", name, "path", path)
+
+			start := time.Now()
+			result, err := next(ctx, s)
+			duration := time.Since(start)
+
+			if err != nil {
+				logger.ErrorContext(ctx, "node failed", "node", name, "path", path, "duration", duration, "error", err)
+				return result, err
+			}
+			logger.InfoContext(ctx, "node finished", "node", name, "path", path, "duration", duration,
+				"next_node", result.NextNode, "done", result.IsDone)
+			return result, nil
+		}
+	}
+}
+
+// Timing reports how long every node execution took, e.g. to feed metrics.
+func Timing(record func(name string, duration time.Duration, err error)) Middleware {
+	return func(name string, next NodeFunc) NodeFunc {
+		return func(ctx context.Context, s State) (NodeResult, error) {
+			start := time.Now()
+			result, err := next(ctx, s)
+			record(name, time.Since(start), err)
+			return result, err
+		}
+	}
+}
+
+// AssertState checks a state invariant after every successful node execution
+// and fails the node when it does not hold. A node that returns no state
+// fails without reaching check, so checks never see a nil State.
+func AssertState(check func(s State) error) Middleware {
+	return func(name string, next NodeFunc) NodeFunc {
+		return func(ctx context.Context, s State) (NodeResult, error) {
+			result, err := next(ctx, s)
+			if err != nil {
+				return result, err
+			}
+			if result.State == nil {
+				return result, fmt.Errorf("state invariant violated after node %s: node returned no state", name)
+			}
+			if err := check(result.State); err != nil {
+				return result, fmt.Errorf("state invariant violated after node %s: %w", name, err)
+			}
+			return result, nil
+		}
+	}
+}
@@ -29,9 +29,9 @@ func SimpleAgentNode(l llm.LLM, instructions string) agora.NodeFunc {
 		}
 
 		// 3. Call the LLM using the new Invoke signature.
-		response, err := l.Invoke(ctx, request)
+		response, err := invoke(ctx, l, request)
 		if err != nil {
-			return agora.NodeResult{State: s}, fmt.Errorf("failed to invoke LLM: %w", err)
+			return agora.NodeResult{State: s}, err
 		}
 
 		// 4. Extract the content.
@@ -58,3 +58,17 @@ func SimpleAgentNode(l llm.LLM, instructions string) agora.NodeFunc {
 		}, nil
 	}
 }
+
+// invoke calls the LLM once the run's budget allows it and reports the
+// response's usage to the run.
+func invoke(ctx context.Context, l llm.LLM, request agora.ModelRequest) (agora.ModelResponse, error) {
+	if err := agora.CheckBudget(ctx); err != nil {
+		return agora.ModelResponse{}, err
+	}
+	response, err := l.Invoke(ctx, request)
+	if err != nil {
+		return agora.ModelResponse{}, fmt.Errorf("failed to invoke LLM: %w", err)
+	}
+	agora.ReportModelResponse(ctx, response)
+	return response, nil
+}
@@ -0,0 +1,158 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/amangsingh/agora"
+)
+
+// MapConfig configures a MapNode.
+type MapConfig struct {
+	// ItemsKey is the state key holding the slice to fan out over.
+	ItemsKey string
+	// ItemKey is the key under which each item is injected into its branch's state.
+	ItemKey string
+	// ResultKey is the key read from each branch's final state. Defaults to "output".
+	ResultKey string
+	// TargetKey is the key the reduced result is written to. Defaults to ItemsKey + "_results".
+	TargetKey string
+	// MaxConcurrency bounds how many branches run at once. Zero means no bound.
+	MaxConcurrency int
+	// Reduce combines the per-item results, in input order, into the value stored
+	// under TargetKey. If nil, the slice of results itself is stored.
+	Reduce func(results []any) (any, error)
+}
+
+// MapNode executes a worker once per item of a slice that is only known at runtime.
+//
+// It reads the slice stored under cfg.ItemsKey, creates a deep copy of the state
+// for every item (the same isolation strategy as ParallelNode), injects the item
+// under cfg.ItemKey, and runs the worker on each copy with at most
+// cfg.MaxConcurrency branches in flight. The value each branch leaves under
+// cfg.ResultKey is collected in input order and reduced into cfg.TargetKey on
+// the original state.
+//
+// If any branch fails, the remaining branches are cancelled and the first error
+// is returned.
+func MapNode(worker agora.NodeFunc, cfg MapConfig) agora.NodeFunc {
+	if cfg.ResultKey == "" {
+		cfg.ResultKey = "output"
+	}
+	if cfg.TargetKey == "" {
+		cfg.TargetKey = cfg.ItemsKey + "_results"
+	}
+
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		// 1. Read the items to fan out over.
+		items, err := sliceItems(s.Get(cfg.ItemsKey))
+		if err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("map node could not read %q: %w", cfg.ItemsKey, err)
+		}
+
+		// 2. Create every branch state up front so a failed copy never leaves
+		// goroutines running behind us.
+		branches := make([]agora.State, len(items))
+		for i, item := range items {
+			stateCopy, err := s.DeepCopy()
+			if err != nil {
+				return agora.NodeResult{State: s}, fmt.Errorf("failed to deep copy state for map item %d: %w", i, err)
+			}
+			stateCopy.Set(cfg.ItemKey, item)
+			branches[i] = stateCopy
+		}
+
+		// 3. Fan-out with bounded concurrency.
+		limit := cfg.MaxConcurrency
+		if limit <= 0 || limit > len(items) {
+			limit = len(items)
+		}
+
+		mapCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var (
+			wg       sync.WaitGroup
+			errOnce  sync.Once
+			firstErr error
+		)
+		results := make([]any, len(items))
+		sem := make(chan struct{}, max(limit, 1))
+
+		for i, branch := range branches {
+			wg.Add(1)
+			go func(i int, st agora.State) {
+				defer wg.Done()
+
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+				case <-mapCtx.Done():
+					return
+				}
+
+				result, err := worker(mapCtx, st)
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = fmt.Errorf("map item %d: %w", i, err)
+						cancel()
+					})
+					return
+				}
+				if result.State != nil {
+					results[i] = result.State.Get(cfg.ResultKey)
+				}
+			}(i, branch)
+		}
+		wg.Wait()
+
+		if firstErr != nil {
+			return agora.NodeResult{State: s}, firstErr
+		}
+		// The parent context may have been cancelled while branches were queued.
+		if err := ctx.Err(); err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+
+		// 4. Fan-in: reduce the ordered results into the target key.
+		var reduced any = results
+		if cfg.Reduce != nil {
+			reduced, err = cfg.Reduce(results)
+			if err != nil {
+				return agora.NodeResult{State: s}, fmt.Errorf("map node reduce failed: %w", err)
+			}
+		}
+		s.Set(cfg.TargetKey, reduced)
+
+		return agora.NodeResult{State: s}, nil
+	}
+}
+
+// MapGraphNode is a MapNode whose worker is an entire sub-graph, executed once
+// per item via SubGraphNode.
+func MapGraphNode(subGraph *agora.Graph, cfg MapConfig) agora.NodeFunc {
+	return MapNode(SubGraphNode(subGraph), cfg)
+}
+
+// sliceItems converts any slice or array value into a []any. A nil value is
+// treated as an empty list.
+func sliceItems(v any) ([]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if items, ok := v.([]any); ok {
+		return items, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a slice, got %T", v)
+	}
+	items := make([]any, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items, nil
+}
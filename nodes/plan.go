@@ -0,0 +1,406 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+// Tool names used by the planner and replanner.
+const (
+	submitPlanToolName = "submit_plan"
+	updatePlanToolName = "update_plan"
+	finishPlanToolName = "finish"
+)
+
+// StepStatus is the lifecycle of a single plan step.
+type StepStatus string
+
+const (
+	StepPending    StepStatus = "pending"
+	StepInProgress StepStatus = "in_progress"
+	StepDone       StepStatus = "done"
+	StepFailed     StepStatus = "failed"
+)
+
+// PlanStep is one unit of work in a Plan.
+type PlanStep struct {
+	Description string     `json:"description"`
+	Status      StepStatus `json:"status"`
+	Result      string     `json:"result,omitempty"`
+}
+
+// Plan is the structured task list a PlanExecuteNode keeps under the "plan" state key.
+type Plan struct {
+	Goal  string     `json:"goal"`
+	Steps []PlanStep `json:"steps"`
+}
+
+// NextStep returns the index of the first pending step.
+func (p Plan) NextStep() (int, bool) {
+	for i, step := range p.Steps {
+		if step.Status == StepPending {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// Attempted returns how many steps have been executed, successfully or not.
+func (p Plan) Attempted() int {
+	n := 0
+	for _, step := range p.Steps {
+		if step.Status == StepDone || step.Status == StepFailed {
+			n++
+		}
+	}
+	return n
+}
+
+// String renders the plan for a prompt.
+func (p Plan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Goal: %s\n", p.Goal)
+	for i, step := range p.Steps {
+		fmt.Fprintf(&b, "%d. [%s] %s\n", i+1, step.Status, step.Description)
+		if step.Result != "" {
+			fmt.Fprintf(&b, "   Result: %s\n", step.Result)
+		}
+	}
+	return b.String()
+}
+
+// PlanFromState reads the typed Plan stored under the "plan" key. The boolean
+// is false when no plan has been written yet.
+func PlanFromState(s agora.State) (Plan, bool, error) {
+	return valueAs[Plan](s, "plan")
+}
+
+// PlanExecuteConfig configures a PlanExecuteNode.
+type PlanExecuteConfig struct {
+	// Planner writes the initial plan. Replanner revises the remaining steps
+	// after every result and defaults to Planner.
+	Planner               llm.LLM
+	PlannerInstructions   string
+	Replanner             llm.LLM
+	ReplannerInstructions string
+
+	// Executor works through one step at a time as a ToolAgentNode loop over Tools.
+	Executor             llm.LLM
+	ExecutorInstructions string
+	Tools                agora.ToolRegistry
+
+	// MaxPlanSteps bounds how many steps are executed in total. Defaults to 10.
+	MaxPlanSteps int
+	// ExecutorMaxSteps is the MaxSteps of each step's tool loop. Defaults to 10.
+	ExecutorMaxSteps int
+}
+
+// NewPlanExecuteGraph builds the graph behind PlanExecuteNode:
+//
+//	plan -> execute -> replan -> (execute | finish)
+//
+// Every step runs in its own ToolAgentNode/ToolExecutorNode sub-graph on a
+// fresh ConversationState, so tool chatter never reaches the parent history.
+func NewPlanExecuteGraph(cfg PlanExecuteConfig) *agora.Graph {
+	if cfg.Replanner == nil {
+		cfg.Replanner = cfg.Planner
+	}
+	if cfg.MaxPlanSteps <= 0 {
+		cfg.MaxPlanSteps = 10
+	}
+	if cfg.ExecutorMaxSteps <= 0 {
+		cfg.ExecutorMaxSteps = 10
+	}
+
+	runStep := SubGraphNode(toolLoopGraph(cfg.Executor, cfg.ExecutorInstructions, cfg.Tools, cfg.ExecutorMaxSteps),
+		WithChildState(func(parent agora.State) (agora.State, error) {
+			prompt, _ := parent.Get("plan_step_prompt").(string)
+			return &agora.ConversationState{BaseState: agora.NewBaseState(), Input: prompt}, nil
+		}),
+		WithOutputs(map[string]string{"output": "plan_step_output"}),
+	)
+
+	g := agora.NewGraph()
+	// One plan step, an execute and replan step per plan step, and the finish step.
+	g.MaxSteps = 2*cfg.MaxPlanSteps + 2
+	g.SetEntry("plan")
+	g.AddNode("plan", plannerStep(cfg))
+	g.AddNode("execute", executorStep(runStep))
+	g.AddNode("replan", replannerStep(cfg))
+	g.AddNode("finish", finishPlanStep)
+
+	g.AddEdge("plan", "execute")
+	g.AddEdge("execute", "replan")
+	g.AddEdge("finish", agora.END)
+	g.SetConditionalEdge("replan", func(s agora.State) string {
+		plan, _, _ := PlanFromState(s)
+		if finished, _ := s.Get("plan_finished").(bool); finished {
+			return "finish"
+		}
+		if _, ok := plan.NextStep(); !ok || plan.Attempted() >= cfg.MaxPlanSteps {
+			return "finish"
+		}
+		return "execute"
+	}, "execute", "finish")
+
+	return g
+}
+
+// PlanExecuteNode is a factory for the plan-and-execute pattern.
+//
+// A planner writes a typed Plan to state, an executor completes the first
+// pending step with a tool-using sub-loop, and a replanner revises the
+// remaining steps after each result or finishes with an answer in "output".
+// The pattern runs as a sub-graph inheriting the parent's step budget.
+func PlanExecuteNode(cfg PlanExecuteConfig) agora.NodeFunc {
+	return SubGraphNode(NewPlanExecuteGraph(cfg), WithInheritedMaxSteps())
+}
+
+// plannerStep asks the planner for the initial list of steps.
+func plannerStep(cfg PlanExecuteConfig) agora.NodeFunc {
+	tool := agora.ToolDefinition{
+		Type: "function",
+		Function: agora.Function{
+			Name:        submitPlanToolName,
+			Description: "Submit the ordered list of steps that accomplishes the user's goal.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"goal":  map[string]interface{}{"type": "string"},
+					"steps": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				},
+				"required": []string{"goal", "steps"},
+			},
+		},
+	}
+
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		messagesForLLM, err := s.ToChatHistory()
+		if err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("could not get chat history: %w", err)
+		}
+
+		msg, err := invokeRequired(ctx, cfg.Planner, cfg.PlannerInstructions, messagesForLLM, tool)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+
+		// Fall back to a single step covering the whole request.
+		plan := Plan{}
+		if len(messagesForLLM) > 0 {
+			plan.Goal = messagesForLLM[len(messagesForLLM)-1].Content
+		}
+		for _, call := range msg.ToolCalls {
+			if call.Function.Name != submitPlanToolName {
+				continue
+			}
+			if goal, ok := call.Function.Arguments["goal"].(string); ok && goal != "" {
+				plan.Goal = goal
+			}
+			for _, d := range stringList(call.Function.Arguments["steps"]) {
+				plan.Steps = append(plan.Steps, PlanStep{Description: d, Status: StepPending})
+			}
+		}
+		if len(plan.Steps) == 0 {
+			plan.Steps = []PlanStep{{Description: plan.Goal, Status: StepPending}}
+		}
+
+		s.Set("plan", plan)
+		s.Set("plan_finished", false)
+		s.Set("output", "")
+		return agora.NodeResult{State: s}, nil
+	}
+}
+
+// executorStep runs the first pending step through the tool loop and records its result.
+func executorStep(runStep agora.NodeFunc) agora.NodeFunc {
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		plan, ok, err := PlanFromState(s)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		i, pending := plan.NextStep()
+		if !ok || !pending {
+			return agora.NodeResult{State: s}, nil
+		}
+
+		// 1. Describe the step with the context of what has been done so far.
+		plan.Steps[i].Status = StepInProgress
+		s.Set("plan", plan)
+		s.Set("plan_step_prompt", fmt.Sprintf("%s\nComplete step %d: %s", plan, i+1, plan.Steps[i].Description))
+
+		// 2. Run the tool loop. A failed step is recorded for the replanner
+		// rather than aborting the whole plan, unless the run was cancelled,
+		// ran out of budget or ran out of steps.
+		result, err := runStep(ctx, s)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, agora.ErrBudgetExceeded) || errors.Is(err, agora.ErrMaxStepsExceeded) {
+				return agora.NodeResult{State: s}, err
+			}
+			plan.Steps[i].Status = StepFailed
+			plan.Steps[i].Result = err.Error()
+		} else {
+			s = result.State
+			output, _ := s.Get("plan_step_output").(string)
+			plan.Steps[i].Status = StepDone
+			plan.Steps[i].Result = output
+		}
+
+		s.Set("plan", plan)
+		return agora.NodeResult{State: s}, nil
+	}
+}
+
+// replannerStep revises the remaining steps or finishes with an answer.
+func replannerStep(cfg PlanExecuteConfig) agora.NodeFunc {
+	tools := []agora.ToolDefinition{
+		{
+			Type: "function",
+			Function: agora.Function{
+				Name:        updatePlanToolName,
+				Description: "Replace the remaining pending steps. Completed steps are kept.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"steps": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+					},
+					"required": []string{"steps"},
+				},
+			},
+		},
+		{
+			Type: "function",
+			Function: agora.Function{
+				Name:        finishPlanToolName,
+				Description: "The goal is accomplished. Give the final answer to the user.",
+				Parameters: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"answer": map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"answer"},
+				},
+			},
+		},
+	}
+
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		plan, _, err := PlanFromState(s)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+
+		prompt := []agora.ChatMessage{{
+			Role:    "user",
+			Content: fmt.Sprintf("Current plan:\n%s\nEither call %s with the remaining steps or call %s with the final answer.", plan, updatePlanToolName, finishPlanToolName),
+		}}
+		msg, err := invokeRequired(ctx, cfg.Replanner, cfg.ReplannerInstructions, prompt, tools...)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+
+		for _, call := range msg.ToolCalls {
+			switch call.Function.Name {
+			case finishPlanToolName:
+				answer, _ := call.Function.Arguments["answer"].(string)
+				s.Set("output", answer)
+				s.Set("plan_finished", true)
+				return agora.NodeResult{State: s}, nil
+			case updatePlanToolName:
+				kept := make([]PlanStep, 0, len(plan.Steps))
+				for _, step := range plan.Steps {
+					if step.Status != StepPending {
+						kept = append(kept, step)
+					}
+				}
+				for _, d := range stringList(call.Function.Arguments["steps"]) {
+					kept = append(kept, PlanStep{Description: d, Status: StepPending})
+				}
+				plan.Steps = kept
+				s.Set("plan", plan)
+				return agora.NodeResult{State: s}, nil
+			}
+		}
+
+		// No usable revision: carry on with the plan as it stands.
+		return agora.NodeResult{State: s}, nil
+	}
+}
+
+// finishPlanStep appends the final answer to history. Without an explicit
+// answer from the replanner, the last step's result is used.
+func finishPlanStep(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+	answer, _ := s.Get("output").(string)
+	if answer == "" {
+		plan, _, err := PlanFromState(s)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		for _, step := range plan.Steps {
+			if step.Status == StepDone {
+				answer = step.Result
+			}
+		}
+		s.Set("output", answer)
+	}
+
+	if err := s.AppendTurn(agora.ChatMessage{Role: "assistant", Content: answer}); err != nil {
+		return agora.NodeResult{State: s}, fmt.Errorf("could not append turn to history: %w", err)
+	}
+	return agora.NodeResult{State: s, IsDone: true}, nil
+}
+
+// toolLoopGraph builds the classic ReAct loop: an agent that may call tools,
+// and an executor that runs them and hands back to the agent.
+func toolLoopGraph(l llm.LLM, instructions string, registry agora.ToolRegistry, maxSteps int) *agora.Graph {
+	g := agora.NewGraph()
+	g.MaxSteps = maxSteps
+	g.SetEntry("agent")
+	g.AddNode("agent", ToolAgentNode(l, instructions, registry))
+	g.AddNode("tools", ToolExecutorNode(registry))
+	g.AddEdge("tools", "agent")
+	g.SetConditionalEdge("agent", func(s agora.State) string {
+		if calls, _, _ := valueAs[[]agora.ToolCall](s, "tool_calls"); len(calls) > 0 {
+			return "tools"
+		}
+		return agora.END
+	}, "tools", agora.END)
+	return g
+}
+
+// invokeRequired calls the LLM with the given tools and tool_choice "required".
+func invokeRequired(ctx context.Context, l llm.LLM, instructions string, messages []agora.ChatMessage, tools ...agora.ToolDefinition) (agora.ChatMessage, error) {
+	fullMessages := append([]agora.ChatMessage{
+		{Role: "system", Content: instructions},
+	}, messages...)
+
+	response, err := invoke(ctx, l, agora.ModelRequest{
+		Messages:   fullMessages,
+		Tools:      tools,
+		ToolChoice: "required",
+	})
+	if err != nil {
+		return agora.ChatMessage{}, err
+	}
+	if len(response.Choices) == 0 {
+		return agora.ChatMessage{}, fmt.Errorf("LLM returned no choices")
+	}
+	return response.Choices[0].Message, nil
+}
+
+// stringList converts a decoded JSON array argument into strings, skipping blanks.
+func stringList(v any) []string {
+	items, _ := sliceItems(v)
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,295 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+// verdictToolName is the tool the LLM critic is forced to call.
+const verdictToolName = "submit_verdict"
+
+// Verdict decisions.
+const (
+	VerdictApprove = "approve"
+	VerdictRevise  = "revise"
+)
+
+// Verdict is a critic's structured judgement of a draft.
+type Verdict struct {
+	Decision string `json:"decision"` // VerdictApprove or VerdictRevise
+	Feedback string `json:"feedback"`
+}
+
+// Approved reports whether the critic accepted the draft.
+func (v Verdict) Approved() bool {
+	return v.Decision == VerdictApprove
+}
+
+// ReflectionConfig configures a ReflectionNode.
+type ReflectionConfig struct {
+	// Generator writes a draft to "output". On later rounds the previous
+	// critique is available under VerdictKey. Generators should not append the
+	// draft to history; the final draft is appended once when the loop ends.
+	Generator agora.NodeFunc
+	// Critic reads the draft from "output" and stores a Verdict under VerdictKey.
+	Critic agora.NodeFunc
+	// MaxRounds bounds the number of generate/critique rounds. Defaults to 3.
+	MaxRounds int
+	// VerdictKey holds the latest Verdict. ReflectionGeneratorNode and
+	// CriticNode follow it. Defaults to "verdict".
+	VerdictKey string
+	// DraftsKey collects every draft. Defaults to "drafts".
+	DraftsKey string
+	// CritiquesKey collects every Verdict. Defaults to "critiques".
+	CritiquesKey string
+	// RoundKey counts the finished rounds. Defaults to "reflection_round".
+	RoundKey string
+}
+
+// reflectionKeyCtx carries ReflectionConfig.VerdictKey to the built-in
+// generator and critic.
+type reflectionKeyCtx struct{}
+
+// verdictKey returns the key the running reflection keeps its verdict under.
+func verdictKey(ctx context.Context) string {
+	if key, ok := ctx.Value(reflectionKeyCtx{}).(string); ok {
+		return key
+	}
+	return "verdict"
+}
+
+// NewReflectionGraph builds the generate -> critique -> revise loop behind
+// ReflectionNode. Every draft is recorded under cfg.DraftsKey and every
+// verdict under cfg.CritiquesKey; the loop ends when the critic approves,
+// MaxRounds pass, or the steps left only allow finishing with the last draft.
+func NewReflectionGraph(cfg ReflectionConfig) *agora.Graph {
+	if cfg.MaxRounds <= 0 {
+		cfg.MaxRounds = 3
+	}
+	if cfg.VerdictKey == "" {
+		cfg.VerdictKey = "verdict"
+	}
+	if cfg.DraftsKey == "" {
+		cfg.DraftsKey = "drafts"
+	}
+	if cfg.CritiquesKey == "" {
+		cfg.CritiquesKey = "critiques"
+	}
+	if cfg.RoundKey == "" {
+		cfg.RoundKey = "reflection_round"
+	}
+
+	g := agora.NewGraph()
+	// Each round is a generate step and a critique step, plus the start and finish steps.
+	g.MaxSteps = 2*cfg.MaxRounds + 2
+	g.SetEntry("start")
+
+	// Clear what a previous reflection on the same state left behind, after
+	// making sure at least one round and the finish fit in the steps left.
+	g.AddNode("start", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		if remaining, ok := agora.RemainingSteps(ctx); ok && remaining < 3 {
+			return agora.NodeResult{State: s}, fmt.Errorf("reflection needs 3 more steps for a round, %d left: %w", remaining, agora.ErrMaxStepsExceeded)
+		}
+		s.Set(cfg.VerdictKey, nil)
+		s.Set(cfg.DraftsKey, nil)
+		s.Set(cfg.CritiquesKey, nil)
+		s.Set(cfg.RoundKey, 0)
+		return agora.NodeResult{State: s}, nil
+	})
+
+	g.AddNode("generate", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		result, err := cfg.Generator(context.WithValue(ctx, reflectionKeyCtx{}, cfg.VerdictKey), s)
+		if err != nil {
+			return result, err
+		}
+		s = result.State
+		draft, _ := s.Get("output").(string)
+		if err := appendValue(s, cfg.DraftsKey, draft); err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		return agora.NodeResult{State: s}, nil
+	})
+
+	g.AddNode("critique", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		result, err := cfg.Critic(context.WithValue(ctx, reflectionKeyCtx{}, cfg.VerdictKey), s)
+		if err != nil {
+			return result, err
+		}
+		s = result.State
+		verdict, ok, err := valueAs[Verdict](s, cfg.VerdictKey)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		if !ok {
+			return agora.NodeResult{State: s}, fmt.Errorf("critic did not store a verdict")
+		}
+		if err := appendValue(s, cfg.CritiquesKey, verdict); err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		round, _, err := valueAs[int](s, cfg.RoundKey)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		s.Set(cfg.RoundKey, round+1)
+
+		// Another round needs a generate, a critique and the finish step; a
+		// parent with fewer left gets the last draft instead.
+		if remaining, ok := agora.RemainingSteps(ctx); ok && remaining < 3 {
+			return agora.NodeResult{State: s, NextNode: "finish"}, nil
+		}
+		return agora.NodeResult{State: s}, nil
+	})
+
+	g.AddNode("finish", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		draft, _ := s.Get("output").(string)
+		if err := s.AppendTurn(agora.ChatMessage{Role: "assistant", Content: draft}); err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("could not append turn to history: %w", err)
+		}
+		return agora.NodeResult{State: s, IsDone: true}, nil
+	})
+
+	g.AddEdge("start", "generate")
+	g.AddEdge("generate", "critique")
+	g.AddEdge("finish", agora.END)
+	g.SetConditionalEdge("critique", func(s agora.State) string {
+		verdict, _, _ := valueAs[Verdict](s, cfg.VerdictKey)
+		round, _, _ := valueAs[int](s, cfg.RoundKey)
+		if verdict.Approved() || round >= cfg.MaxRounds {
+			return "finish"
+		}
+		return "generate"
+	}, "generate", "finish")
+
+	return g
+}
+
+// ReflectionNode is a factory for a generate -> critique -> revise loop.
+//
+// It runs until the critic approves or cfg.MaxRounds pass, whichever comes
+// first. The loop executes as a sub-graph that inherits the parent's remaining
+// step budget, so it can never outrun the parent graph's MaxSteps: when the
+// budget runs short it finishes early with the latest draft.
+func ReflectionNode(cfg ReflectionConfig) agora.NodeFunc {
+	return SubGraphNode(NewReflectionGraph(cfg), WithInheritedMaxSteps())
+}
+
+// ReflectionGeneratorNode creates an LLM generator for ReflectionNode. On
+// revision rounds it shows the model its previous draft and the critic's
+// feedback. The draft is written to "output" without touching history.
+func ReflectionGeneratorNode(l llm.LLM, instructions string) agora.NodeFunc {
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		// 1. Get the conversation the draft answers.
+		messagesForLLM, err := s.ToChatHistory()
+		if err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("could not get chat history: %w", err)
+		}
+
+		// 2. Fold the previous critique into the instructions.
+		systemPrompt := instructions
+		verdict, ok, err := valueAs[Verdict](s, verdictKey(ctx))
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		if ok && !verdict.Approved() {
+			previous, _ := s.Get("output").(string)
+			systemPrompt = fmt.Sprintf("%s\n\nYour previous draft was:\n%s\n\nA reviewer asked for these changes:\n%s\n\nWrite an improved draft.",
+				instructions, previous, verdict.Feedback)
+		}
+
+		fullMessages := append([]agora.ChatMessage{
+			{Role: "system", Content: systemPrompt},
+		}, messagesForLLM...)
+
+		// 3. Call the LLM.
+		response, err := invoke(ctx, l, agora.ModelRequest{Messages: fullMessages})
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		if len(response.Choices) == 0 {
+			return agora.NodeResult{State: s}, fmt.Errorf("LLM returned no choices")
+		}
+
+		s.Set("output", response.Choices[0].Message.Content)
+		return agora.NodeResult{State: s}, nil
+	}
+}
+
+// CriticNode creates an LLM critic for ReflectionNode. The model is forced to
+// call a submit_verdict tool so its judgement is structured; an answer without
+// a valid verdict is treated as a request to revise.
+func CriticNode(l llm.LLM, instructions string) agora.NodeFunc {
+	tool := agora.ToolDefinition{
+		Type: "function",
+		Function: agora.Function{
+			Name:        verdictToolName,
+			Description: "Approve the draft or ask for a revision.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"decision": map[string]interface{}{
+						"type": "string",
+						"enum": []string{VerdictApprove, VerdictRevise},
+					},
+					"feedback": map[string]interface{}{
+						"type":        "string",
+						"description": "What must change for the draft to be approved.",
+					},
+				},
+				"required": []string{"decision", "feedback"},
+			},
+		},
+	}
+
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		// 1. Show the critic the conversation and the draft under review.
+		messagesForLLM, err := s.ToChatHistory()
+		if err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("could not get chat history: %w", err)
+		}
+		draft, _ := s.Get("output").(string)
+
+		fullMessages := append([]agora.ChatMessage{
+			{Role: "system", Content: instructions},
+		}, messagesForLLM...)
+		fullMessages = append(fullMessages, agora.ChatMessage{
+			Role:    "user",
+			Content: fmt.Sprintf("Review this draft and call %s:\n\n%s", verdictToolName, draft),
+		})
+
+		// 2. Force a structured verdict.
+		response, err := invoke(ctx, l, agora.ModelRequest{
+			Messages:   fullMessages,
+			Tools:      []agora.ToolDefinition{tool},
+			ToolChoice: "required",
+		})
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		if len(response.Choices) == 0 {
+			return agora.NodeResult{State: s}, fmt.Errorf("LLM returned no choices")
+		}
+
+		s.Set(verdictKey(ctx), parseVerdict(response.Choices[0].Message))
+		return agora.NodeResult{State: s}, nil
+	}
+}
+
+// parseVerdict reads the submit_verdict call, falling back to a revision
+// request that carries whatever the model said.
+func parseVerdict(msg agora.ChatMessage) Verdict {
+	for _, call := range msg.ToolCalls {
+		if call.Function.Name != verdictToolName {
+			continue
+		}
+		decision, _ := call.Function.Arguments["decision"].(string)
+		feedback, _ := call.Function.Arguments["feedback"].(string)
+		if decision == VerdictApprove || decision == VerdictRevise {
+			return Verdict{Decision: decision, Feedback: feedback}
+		}
+	}
+	return Verdict{Decision: VerdictRevise, Feedback: strings.TrimSpace(msg.Content)}
+}
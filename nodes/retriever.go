@@ -0,0 +1,179 @@
+package nodes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+	"github.com/amangsingh/agora/vectorstore"
+)
+
+// RetrieverConfig configures a RetrieverNode.
+type RetrieverConfig struct {
+	Embedder llm.Embedder
+	Store    vectorstore.VectorStore
+	// TopK is how many chunks to retrieve. Defaults to 4.
+	TopK int
+	// Filter restricts retrieval by metadata, e.g. to one language.
+	Filter vectorstore.Filter
+	// MinScore drops chunks scoring below it. Zero keeps everything.
+	MinScore float64
+	// Query extracts the search text from the state. Defaults to the latest
+	// user message.
+	Query func(s agora.State) (string, error)
+}
+
+// RetrieverNode embeds the current user input and fetches the most similar
+// chunks from the store. They are saved under "retrieved" as a numbered
+// list, and under "context" formatted for a prompt, for RAGAgentNode or any
+// other node to cite.
+func RetrieverNode(cfg RetrieverConfig) agora.NodeFunc {
+	if cfg.TopK <= 0 {
+		cfg.TopK = 4
+	}
+	if cfg.Query == nil {
+		cfg.Query = latestUserMessage
+	}
+
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		// 1. Embed the query.
+		query, err := cfg.Query(s)
+		if err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("could not get retrieval query: %w", err)
+		}
+		vectors, err := cfg.Embedder.Embed(ctx, []string{query})
+		if err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("failed to embed query: %w", err)
+		}
+		if len(vectors) != 1 {
+			return agora.NodeResult{State: s}, fmt.Errorf("expected 1 embedding, got %d", len(vectors))
+		}
+
+		// 2. Search, dropping weak matches and the vectors nobody needs in state.
+		results, err := cfg.Store.Search(ctx, vectors[0], cfg.TopK, cfg.Filter)
+		if err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("failed to search vector store: %w", err)
+		}
+		retrieved := make([]vectorstore.Result, 0, len(results))
+		for _, r := range results {
+			if cfg.MinScore != 0 && r.Score < cfg.MinScore {
+				continue
+			}
+			r.Vector = nil
+			retrieved = append(retrieved, r)
+		}
+
+		// 3. Save them for the answering node.
+		s.Set("retrieved", retrieved)
+		s.Set("context", FormatContext(retrieved))
+		return agora.NodeResult{State: s}, nil
+	}
+}
+
+// FormatContext numbers retrieved chunks for a prompt, so an answer can cite
+// them as [1], [2] and so on.
+func FormatContext(retrieved []vectorstore.Result) string {
+	var b strings.Builder
+	for i, r := range retrieved {
+		fmt.Fprintf(&b, "[%d] (source: %s)\n%s\n\n", i+1, r.ID, strings.TrimSpace(r.Content))
+	}
+	return strings.TrimSpace(b.String())
+}
+
+var citationPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// RAGAgentNode answers from the chunks a RetrieverNode saved in the state,
+// asking the model to cite them by number. The IDs of the cited chunks are
+// saved under "sources", in order of first citation.
+func RAGAgentNode(l llm.LLM, instructions string) agora.NodeFunc {
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		// 1. Build the grounded prompt.
+		retrieved, err := retrievedFrom(s)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		messagesForLLM, err := s.ToChatHistory()
+		if err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("could not get chat history: %w", err)
+		}
+		system := instructions
+		if len(retrieved) > 0 {
+			system += "\n\nAnswer using the numbered sources below. Cite every source you use by its number in square brackets, like [1]. If they do not contain the answer, say so.\n\n" + FormatContext(retrieved)
+		} else {
+			system += "\n\nNo sources were found for this question. Say so rather than guessing."
+		}
+		fullMessages := append([]agora.ChatMessage{
+			{Role: "system", Content: system},
+		}, messagesForLLM...)
+
+		// 2. Call the LLM.
+		response, err := invoke(ctx, l, agora.ModelRequest{Messages: fullMessages})
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		if len(response.Choices) == 0 {
+			return agora.NodeResult{State: s}, fmt.Errorf("LLM returned no choices")
+		}
+		answer := response.Choices[0].Message
+
+		// 3. Resolve the citations to chunk IDs, ignoring numbers out of range.
+		sources := []string{}
+		seen := map[int]bool{}
+		for _, m := range citationPattern.FindAllStringSubmatch(answer.Content, -1) {
+			n, _ := strconv.Atoi(m[1])
+			if n >= 1 && n <= len(retrieved) && !seen[n] {
+				seen[n] = true
+				sources = append(sources, retrieved[n-1].ID)
+			}
+		}
+
+		// 4. Update State
+		s.Set("output", answer.Content)
+		s.Set("sources", sources)
+		if err := s.AppendTurn(answer); err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("could not append turn to history: %w", err)
+		}
+		return agora.NodeResult{State: s}, nil
+	}
+}
+
+// retrievedFrom reads the chunks saved by RetrieverNode. After a checkpoint
+// round-trip they are generic JSON, so anything else is decoded again.
+func retrievedFrom(s agora.State) ([]vectorstore.Result, error) {
+	switch v := s.Get("retrieved").(type) {
+	case nil:
+		return nil, nil
+	case []vectorstore.Result:
+		return v, nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retrieved chunks in state: %w", err)
+		}
+		var retrieved []vectorstore.Result
+		if err := json.Unmarshal(data, &retrieved); err != nil {
+			return nil, fmt.Errorf("invalid retrieved chunks in state: %w", err)
+		}
+		return retrieved, nil
+	}
+}
+
+// latestUserMessage returns the content of the last user message in the
+// state's chat history.
+func latestUserMessage(s agora.State) (string, error) {
+	messages, err := s.ToChatHistory()
+	if err != nil {
+		return "", err
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content, nil
+		}
+	}
+	return "", fmt.Errorf("no user message in history")
+}
@@ -0,0 +1,168 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+// routeToolName is the tool the router forces the model to call.
+const routeToolName = "choose_route"
+
+// Route is one destination a RouterNode may choose. Name is the node to jump to.
+type Route struct {
+	Name        string
+	Description string
+}
+
+// RouterConfig configures a RouterNode.
+type RouterConfig struct {
+	// Instructions describe how the model should decide between routes.
+	Instructions string
+	// Routes is the closed set of destinations the model may pick from.
+	Routes []Route
+	// Default is taken when the model's answer is missing or not a declared route.
+	// Defaults to the first route.
+	Default string
+	// DecisionKey is the state key the chosen route is stored under. Defaults to "route".
+	// The model's rationale is stored under DecisionKey + "_rationale".
+	DecisionKey string
+}
+
+// RouterNode is a factory for an LLM-driven branching node.
+//
+// It asks the model to pick one of cfg.Routes by forcing a call to a
+// "choose_route" tool whose route parameter is an enum of the declared names.
+// Any other answer falls back to cfg.Default. The decision and rationale are
+// recorded in state and the node jumps to the chosen route via NextNode.
+func RouterNode(l llm.LLM, cfg RouterConfig) agora.NodeFunc {
+	if cfg.Default == "" && len(cfg.Routes) > 0 {
+		cfg.Default = cfg.Routes[0].Name
+	}
+	if cfg.DecisionKey == "" {
+		cfg.DecisionKey = "route"
+	}
+	tool := routeTool(cfg.Routes)
+	systemPrompt := routerPrompt(cfg)
+
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		// 1. Get the conversation the decision is based on.
+		messagesForLLM, err := s.ToChatHistory()
+		if err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("could not get chat history: %w", err)
+		}
+
+		fullMessages := append([]agora.ChatMessage{
+			{Role: "system", Content: systemPrompt},
+		}, messagesForLLM...)
+
+		// 2. Force a constrained choice through the routing tool.
+		request := agora.ModelRequest{
+			Messages:   fullMessages,
+			Tools:      []agora.ToolDefinition{tool},
+			ToolChoice: "required",
+		}
+
+		response, err := invoke(ctx, l, request)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+
+		// 3. Validate the answer, falling back to the default route.
+		route, rationale := cfg.Default, "fallback: model did not choose a declared route"
+		if len(response.Choices) > 0 {
+			if chosen, why, ok := parseRoute(response.Choices[0].Message, cfg.Routes); ok {
+				route, rationale = chosen, why
+			}
+		}
+
+		// 4. Record the decision. The router's own message is deliberately kept
+		// out of the conversation history.
+		s.Set(cfg.DecisionKey, route)
+		s.Set(cfg.DecisionKey+"_rationale", rationale)
+
+		return agora.NodeResult{State: s, NextNode: route}, nil
+	}
+}
+
+// RouteNames returns the names of the given routes, for declaring a router's
+// destinations: g.AddNode("router", RouterNode(l, cfg), agora.WithRoutes(RouteNames(cfg.Routes)...)).
+func RouteNames(routes []Route) []string {
+	names := make([]string, len(routes))
+	for i, r := range routes {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// routeTool builds the tool definition whose route argument is an enum of the route names.
+func routeTool(routes []Route) agora.ToolDefinition {
+	return agora.ToolDefinition{
+		Type: "function",
+		Function: agora.Function{
+			Name:        routeToolName,
+			Description: "Choose the route the conversation should take next.",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"route": map[string]interface{}{
+						"type": "string",
+						"enum": RouteNames(routes),
+					},
+					"rationale": map[string]interface{}{
+						"type":        "string",
+						"description": "A short explanation of why this route was chosen.",
+					},
+				},
+				"required": []string{"route", "rationale"},
+			},
+		},
+	}
+}
+
+// routerPrompt combines the user's instructions with the list of routes.
+func routerPrompt(cfg RouterConfig) string {
+	var b strings.Builder
+	if cfg.Instructions != "" {
+		b.WriteString(cfg.Instructions)
+		b.WriteString("\n\n")
+	}
+	fmt.Fprintf(&b, "Call the %s tool with exactly one of the following routes:\n", routeToolName)
+	for _, r := range cfg.Routes {
+		fmt.Fprintf(&b, "- %s: %s\n", r.Name, r.Description)
+	}
+	return b.String()
+}
+
+// parseRoute extracts a declared route from the model's message. It accepts a
+// choose_route tool call, or a bare route name as content for models that
+// ignore tool_choice.
+func parseRoute(msg agora.ChatMessage, routes []Route) (string, string, bool) {
+	declared := func(name string) bool {
+		for _, r := range routes {
+			if r.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, call := range msg.ToolCalls {
+		if call.Function.Name != routeToolName {
+			continue
+		}
+		route, _ := call.Function.Arguments["route"].(string)
+		rationale, _ := call.Function.Arguments["rationale"].(string)
+		if declared(route) {
+			return route, rationale, true
+		}
+	}
+
+	if content := strings.TrimSpace(msg.Content); declared(content) {
+		return content, "", true
+	}
+	return "", "", false
+}
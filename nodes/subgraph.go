@@ -2,26 +2,149 @@ package nodes
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/amangsingh/agora"
 )
 
+// SubGraphOption configures how a SubGraphNode hands state to and from its child graph.
+type SubGraphOption func(*subGraphConfig)
+
+type subGraphConfig struct {
+	childState   func(parent agora.State) (agora.State, error)
+	inputs       map[string]string
+	outputs      map[string]string
+	isolate      bool
+	maxSteps     int
+	inheritSteps bool
+}
+
+// WithChildState runs the child graph on a fresh state built by factory instead
+// of the parent's state. The child state may be a different State type; only
+// keys listed in WithOutputs flow back to the parent.
+func WithChildState(factory func(parent agora.State) (agora.State, error)) SubGraphOption {
+	return func(c *subGraphConfig) {
+		c.childState = factory
+	}
+}
+
+// WithInputs copies values from the parent state into the child state before
+// execution. The mapping is parent key -> child key.
+func WithInputs(mapping map[string]string) SubGraphOption {
+	return func(c *subGraphConfig) {
+		c.inputs = mapping
+	}
+}
+
+// WithOutputs copies values from the child's final state back into the parent
+// state after execution. The mapping is child key -> parent key.
+func WithOutputs(mapping map[string]string) SubGraphOption {
+	return func(c *subGraphConfig) {
+		c.outputs = mapping
+	}
+}
+
+// WithIsolatedHistory runs the child graph on a deep copy of the parent state.
+// The parent's history and keys are left untouched; only keys listed in
+// WithOutputs flow back.
+func WithIsolatedHistory() SubGraphOption {
+	return func(c *subGraphConfig) {
+		c.isolate = true
+	}
+}
+
+// WithMaxSteps overrides the child graph's MaxSteps for this node only.
+func WithMaxSteps(n int) SubGraphOption {
+	return func(c *subGraphConfig) {
+		c.maxSteps = n
+	}
+}
+
+// WithInheritedMaxSteps caps the child graph's MaxSteps at the step budget the
+// parent graph has left, so nested loops cannot outrun the parent's circuit breaker.
+func WithInheritedMaxSteps() SubGraphOption {
+	return func(c *subGraphConfig) {
+		c.inheritSteps = true
+	}
+}
+
 // SubGraphNode creates a NodeFunc that executes an entire sub-graph as a single step.
 // This is the core mechanism for hierarchical agent composition.
-func SubGraphNode(subGraph *agora.Graph) agora.NodeFunc {
+//
+// By default the child graph shares the parent's state. Options allow mapping
+// selected keys into an isolated or entirely fresh child state and back out again.
+// Nodes inside the child are attributed as "parent/child/node" in errors.
+func SubGraphNode(subGraph *agora.Graph, opts ...SubGraphOption) agora.NodeFunc {
+	var cfg subGraphConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
-		// 1. Execute the provided sub-graph, passing it the current state.
+		// 1. Build the state the child graph will run on.
+		shared := cfg.childState == nil && !cfg.isolate
+		childState := s
+		switch {
+		case cfg.childState != nil:
+			fresh, err := cfg.childState(s)
+			if err != nil {
+				return agora.NodeResult{State: s}, fmt.Errorf("failed to build child state: %w", err)
+			}
+			childState = fresh
+		case cfg.isolate:
+			stateCopy, err := s.DeepCopy()
+			if err != nil {
+				return agora.NodeResult{State: s}, fmt.Errorf("failed to deep copy state for sub-graph: %w", err)
+			}
+			childState = stateCopy
+		}
+		for parentKey, childKey := range cfg.inputs {
+			childState.Set(childKey, s.Get(parentKey))
+		}
+
+		// 2. Resolve the step budget without mutating the shared sub-graph.
+		run := subGraph
+		if maxSteps := cfg.resolveMaxSteps(ctx, subGraph.MaxSteps); maxSteps != subGraph.MaxSteps {
+			graphCopy := *subGraph
+			graphCopy.MaxSteps = maxSteps
+			run = &graphCopy
+		}
+
+		// 3. Execute the sub-graph.
 		// This is a blocking call; the parent graph waits for the sub-graph to finish.
-		finalStateFromSubGraph, err := subGraph.Execute(ctx, s)
+		finalStateFromSubGraph, err := run.Execute(ctx, childState)
 		if err != nil {
 			// If the sub-graph fails, propagate the error up.
 			return agora.NodeResult{State: s}, err
 		}
 
-		// 2. The execution was successful. The final state of the sub-graph
-		// now becomes the new state of the parent graph.
+		// 4. The execution was successful. In shared mode the final state of the
+		// sub-graph becomes the new state of the parent graph; otherwise only the
+		// mapped outputs are copied back.
+		parentState := s
+		if shared {
+			parentState = finalStateFromSubGraph
+		}
+		for childKey, parentKey := range cfg.outputs {
+			parentState.Set(parentKey, finalStateFromSubGraph.Get(childKey))
+		}
+
 		return agora.NodeResult{
-			State: finalStateFromSubGraph,
+			State: parentState,
 		}, nil
 	}
 }
+
+// resolveMaxSteps applies the override and inheritance options to the child's own limit.
+func (c subGraphConfig) resolveMaxSteps(ctx context.Context, own int) int {
+	maxSteps := own
+	if c.maxSteps > 0 {
+		maxSteps = c.maxSteps
+	}
+	if c.inheritSteps {
+		if remaining, ok := agora.RemainingSteps(ctx); ok && remaining < maxSteps {
+			maxSteps = remaining
+		}
+	}
+	return maxSteps
+}
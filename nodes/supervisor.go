@@ -0,0 +1,248 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+// supervisorNodeName is the name of the supervising node inside a supervisor graph.
+const supervisorNodeName = "supervisor"
+
+// Worker is a named agent that a supervisor can delegate to.
+type Worker struct {
+	Name        string
+	Description string
+	Node        agora.NodeFunc
+}
+
+// GraphWorker wraps an entire graph as a Worker by running it through SubGraphNode.
+func GraphWorker(name, description string, g *agora.Graph, opts ...SubGraphOption) Worker {
+	return Worker{Name: name, Description: description, Node: SubGraphNode(g, opts...)}
+}
+
+// SupervisorConfig configures a SupervisorNode.
+type SupervisorConfig struct {
+	// Instructions tell the supervising model how to split work between workers.
+	Instructions string
+	// Workers are the agents the supervisor may delegate to. "supervisor" is a reserved name.
+	Workers []Worker
+	// MaxRounds bounds how many times the supervisor may delegate. Defaults to 10.
+	MaxRounds int
+	// ActiveKey is the state key tracking which agent is currently active.
+	// Defaults to "active_agent".
+	ActiveKey string
+}
+
+// NewSupervisorGraph builds the graph behind SupervisorNode: a "supervisor"
+// node that delegates through generated transfer_to_<worker> tools, and one
+// node per worker with an edge back to the supervisor.
+func NewSupervisorGraph(l llm.LLM, cfg SupervisorConfig) *agora.Graph {
+	if cfg.MaxRounds <= 0 {
+		cfg.MaxRounds = 10
+	}
+	if cfg.ActiveKey == "" {
+		cfg.ActiveKey = "active_agent"
+	}
+
+	g := agora.NewGraph()
+	// Every round is one supervisor step plus one worker step, and the
+	// supervisor needs a final step to answer.
+	g.MaxSteps = 2*cfg.MaxRounds + 1
+	g.SetEntry(supervisorNodeName)
+	routes := []string{agora.END}
+	for _, w := range cfg.Workers {
+		routes = append(routes, w.Name)
+	}
+	g.AddNode(supervisorNodeName, supervisorStep(l, cfg), agora.WithRoutes(routes...))
+	for _, w := range cfg.Workers {
+		g.AddNode(w.Name, w.Node)
+		g.AddEdge(w.Name, supervisorNodeName)
+	}
+	return g
+}
+
+// SupervisorNode is a factory for a multi-agent team led by a supervising LLM.
+//
+// The supervisor is offered one transfer_to_<worker> tool per worker. Calling
+// one hands control to that worker; once the worker finishes, control returns
+// to the supervisor. When the supervisor answers without delegating, its
+// answer becomes the output and the team is done. The currently active agent
+// is tracked in state under cfg.ActiveKey.
+//
+// The team runs as a sub-graph, so worker failures are attributed as
+// "<node>/<worker>/..." and its steps count against the parent's budget.
+func SupervisorNode(l llm.LLM, cfg SupervisorConfig) agora.NodeFunc {
+	return SubGraphNode(NewSupervisorGraph(l, cfg), WithInheritedMaxSteps())
+}
+
+// supervisorStep is a single supervisor turn: delegate to a worker or finish.
+func supervisorStep(l llm.LLM, cfg SupervisorConfig) agora.NodeFunc {
+	peers := make([]Route, len(cfg.Workers))
+	for i, w := range cfg.Workers {
+		peers[i] = Route{Name: w.Name, Description: w.Description}
+	}
+	tools := handoffTools(peers)
+
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		s.Set(cfg.ActiveKey, supervisorNodeName)
+
+		// 1. Ask the supervisor for its next move.
+		assistantMessage, err := invokeWithTools(ctx, l, cfg.Instructions, s, tools)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		if err := s.AppendTurn(assistantMessage); err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("could not append turn to history: %w", err)
+		}
+
+		// 2. Delegate if a transfer tool was called.
+		if target, ok := handoffTarget(assistantMessage, peers); ok {
+			if err := answerHandoffCalls(s, assistantMessage, target); err != nil {
+				return agora.NodeResult{State: s}, err
+			}
+			s.Set(cfg.ActiveKey, target)
+			return agora.NodeResult{State: s, NextNode: target}, nil
+		}
+
+		// 3. Otherwise the supervisor has produced the final answer.
+		s.Set("output", assistantMessage.Content)
+		return agora.NodeResult{State: s, IsDone: true}, nil
+	}
+}
+
+// HandoffTool builds the transfer_to_<target> tool an agent calls to hand the
+// conversation to a peer.
+func HandoffTool(target, description string) agora.ToolDefinition {
+	return agora.ToolDefinition{
+		Type: "function",
+		Function: agora.Function{
+			Name:        transferToolName(target),
+			Description: fmt.Sprintf("Transfer the conversation to %s. %s", target, description),
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+	}
+}
+
+// HandoffAgentNode is a ToolAgentNode that can also hand the conversation to
+// peer nodes. Each peer is offered as a transfer_to_<name> tool; when the model
+// calls one, the node records the new active agent under "active_agent" and
+// jumps straight to that peer via NextNode. Ordinary tool calls are left in
+// state for a ToolExecutorNode exactly as ToolAgentNode does.
+func HandoffAgentNode(l llm.LLM, instructions string, registry agora.ToolRegistry, peers []Route) agora.NodeFunc {
+	tools := append(registry.GetDefinitions(), handoffTools(peers)...)
+
+	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		// 1. Call the LLM with both the registry tools and the handoff tools.
+		assistantMessage, err := invokeWithTools(ctx, l, instructions, s, tools)
+		if err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		if err := s.AppendTurn(assistantMessage); err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("could not append turn to history: %w", err)
+		}
+
+		// 2. A handoff takes priority over any other tool calls.
+		if target, ok := handoffTarget(assistantMessage, peers); ok {
+			if err := answerHandoffCalls(s, assistantMessage, target); err != nil {
+				return agora.NodeResult{State: s}, err
+			}
+			s.Set("tool_calls", nil)
+			s.Set("active_agent", target)
+			return agora.NodeResult{State: s, NextNode: target}, nil
+		}
+
+		// 3. Behave like ToolAgentNode otherwise.
+		if len(assistantMessage.ToolCalls) > 0 {
+			s.Set("tool_calls", assistantMessage.ToolCalls)
+			s.Set("output", "")
+		} else {
+			s.Set("output", assistantMessage.Content)
+		}
+		return agora.NodeResult{State: s}, nil
+	}
+}
+
+// transferToolName is the name of the tool that hands control to target.
+func transferToolName(target string) string {
+	return "transfer_to_" + target
+}
+
+// handoffTools builds one transfer tool per peer.
+func handoffTools(peers []Route) []agora.ToolDefinition {
+	tools := make([]agora.ToolDefinition, len(peers))
+	for i, p := range peers {
+		tools[i] = HandoffTool(p.Name, p.Description)
+	}
+	return tools
+}
+
+// handoffTarget returns the first peer the message transfers to.
+func handoffTarget(msg agora.ChatMessage, peers []Route) (string, bool) {
+	for _, call := range msg.ToolCalls {
+		name, ok := strings.CutPrefix(call.Function.Name, "transfer_to_")
+		if !ok {
+			continue
+		}
+		for _, p := range peers {
+			if p.Name == name {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// answerHandoffCalls appends a tool response for every call in msg so the
+// history stays valid for providers that require one per tool call.
+func answerHandoffCalls(s agora.State, msg agora.ChatMessage, target string) error {
+	for _, call := range msg.ToolCalls {
+		content := fmt.Sprintf("Transferred to %s.", target)
+		if call.Function.Name != transferToolName(target) {
+			content = fmt.Sprintf("Skipped '%s': the conversation was handed off to %s.", call.Function.Name, target)
+		}
+		toolResponseMessage := agora.ChatMessage{
+			Role:       "tool",
+			ToolCallID: call.ID,
+			Content:    content,
+		}
+		if err := s.AppendTurn(toolResponseMessage); err != nil {
+			return fmt.Errorf("could not append handoff response to history: %w", err)
+		}
+	}
+	return nil
+}
+
+// invokeWithTools sends the instructions, history and tools to the LLM and
+// returns the first choice's message.
+func invokeWithTools(ctx context.Context, l llm.LLM, instructions string, s agora.State, tools []agora.ToolDefinition) (agora.ChatMessage, error) {
+	messagesForLLM, err := s.ToChatHistory()
+	if err != nil {
+		return agora.ChatMessage{}, fmt.Errorf("could not get chat history: %w", err)
+	}
+
+	fullMessages := append([]agora.ChatMessage{
+		{Role: "system", Content: instructions},
+	}, messagesForLLM...)
+
+	request := agora.ModelRequest{
+		Messages:   fullMessages,
+		Tools:      tools,
+		ToolChoice: "auto",
+	}
+
+	response, err := invoke(ctx, l, request)
+	if err != nil {
+		return agora.ChatMessage{}, err
+	}
+	if len(response.Choices) == 0 {
+		return agora.ChatMessage{}, fmt.Errorf("LLM returned no choices")
+	}
+	return response.Choices[0].Message, nil
+}
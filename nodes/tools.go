@@ -32,9 +32,9 @@ func ToolAgentNode(l llm.LLM, instructions string, registry agora.ToolRegistry)
 		}
 
 		// 4. Call the LLM
-		response, err := l.Invoke(ctx, request)
+		response, err := invoke(ctx, l, request)
 		if err != nil {
-			return agora.NodeResult{State: s}, fmt.Errorf("failed to invoke LLM: %w", err)
+			return agora.NodeResult{State: s}, err
 		}
 
 		// 5. Append thoughts/response to history
@@ -66,24 +66,15 @@ func ToolAgentNode(l llm.LLM, instructions string, registry agora.ToolRegistry)
 // ToolExecutorNode creates a NodeFunc that executes tool calls found in the state.
 func ToolExecutorNode(registry agora.ToolRegistry) agora.NodeFunc {
 	return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
-		// 1. Check for tool calls in state.
-		toolCallsData := s.Get("tool_calls")
-		if toolCallsData == nil {
-			// No tool calls to process, return early.
-			return agora.NodeResult{State: s}, nil
-		}
-
-		// 2. Safely cast the data to a slice of ToolCall.
-		// Use generic casting assurance if possible or mapstructure in robust systems.
-		// For now we assume direct casting works if it was set correctly.
-		toolCalls, ok := toolCallsData.([]agora.ToolCall)
-		if !ok {
-			// Try to recover if it was deserialized as generic maps (common in JSON roundtrips)
-			// But for strict Go types in memory, this should work.
-			return agora.NodeResult{State: s}, fmt.Errorf("invalid tool calls format in state")
+		// 1. Read the tool calls in state, also after a JSON round trip such
+		// as a DeepCopy or a state restored from a checkpoint.
+		toolCalls, ok, err := valueAs[[]agora.ToolCall](s, "tool_calls")
+		if err != nil {
+			return agora.NodeResult{State: s}, fmt.Errorf("invalid tool calls format in state: %w", err)
 		}
 
-		if len(toolCalls) == 0 {
+		// 2. No tool calls to process, return early.
+		if !ok || len(toolCalls) == 0 {
 			return agora.NodeResult{State: s}, nil
 		}
 
@@ -95,7 +86,10 @@ func ToolExecutorNode(registry agora.ToolRegistry) agora.NodeFunc {
 			if !exists {
 				resultStr = fmt.Sprintf("Error: Tool '%s' not found", call.Function.Name)
 			} else {
-				// Execute the tool
+				// Execute the tool, within the execution's tool call budget.
+				if err := agora.ReportToolCall(ctx); err != nil {
+					return agora.NodeResult{State: s}, err
+				}
 				result, err := tool.Execute(ctx, call.Function.Arguments)
 				if err != nil {
 					resultStr = fmt.Sprintf("Error executing tool '%s': %v", call.Function.Name, err)
@@ -0,0 +1,42 @@
+package nodes
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/amangsingh/agora"
+)
+
+// valueAs reads key from the state as a T. The boolean is false when the key
+// is unset. Values that lost their concrete type in a JSON-based DeepCopy
+// (a struct that became a map, an int that became a float64) are converted
+// back through JSON.
+func valueAs[T any](s agora.State, key string) (T, bool, error) {
+	var out T
+	raw := s.Get(key)
+	if raw == nil {
+		return out, false, nil
+	}
+	if typed, ok := raw.(T); ok {
+		return typed, true, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return out, false, fmt.Errorf("failed to marshal state key %q: %w", key, err)
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return out, false, fmt.Errorf("state key %q holds %T, not %T: %w", key, raw, out, err)
+	}
+	return out, true, nil
+}
+
+// appendValue appends v to the list stored under key.
+func appendValue[T any](s agora.State, key string, v T) error {
+	list, _, err := valueAs[[]T](s, key)
+	if err != nil {
+		return err
+	}
+	s.Set(key, append(list, v))
+	return nil
+}
@@ -15,11 +15,19 @@ type GraphConfig struct {
 }
 
 type NodeGen struct {
-	Name         string   `yaml:"name"`
-	Type         string   `yaml:"type"` // "agent", "tool", "subgraph"
-	Model        string   `yaml:"model,omitempty"`
-	Instructions string   `yaml:"instructions,omitempty"` // For agents
-	Tools        []string `yaml:"tools,omitempty"`        // List of tool names
+	Name         string     `yaml:"name"`
+	Type         string     `yaml:"type"` // "agent", "router", "tool", "subgraph"
+	Model        string     `yaml:"model,omitempty"`
+	Instructions string     `yaml:"instructions,omitempty"` // For agents and routers
+	Tools        []string   `yaml:"tools,omitempty"`        // List of tool names
+	Routes       []RouteGen `yaml:"routes,omitempty"`       // For routers
+	Default      string     `yaml:"default,omitempty"`      // Fallback route for routers
+}
+
+// RouteGen is one branch a router node may choose.
+type RouteGen struct {
+	To          string `yaml:"to"`
+	Description string `yaml:"description,omitempty"`
 }
 
 type EdgeGen struct {
@@ -110,6 +110,9 @@ func TestGraphExecution(t *testing.T) {
 	{{if eq .Type "agent"}}
 	// Mocking {{.Name}}
 	g.AddNode("{{.Name}}", nodes.SimpleAgentNode(mock, "System Prompt"))
+	{{else if eq .Type "router"}}
+	// Mocking {{.Name}}: the mock never calls choose_route, so the default branch is taken.
+	g.AddNode("{{.Name}}", nodes.RouterNode(mock, routerConfig_{{.Name}}))
 	{{end}}
 	{{end}}
 
@@ -135,6 +138,12 @@ func TestGraphExecution(t *testing.T) {
 		}
 	}
 }
+
+func TestGraphValidates(t *testing.T) {
+	if err := NewGraph().Validate(); err != nil {
+		t.Fatalf("Generated graph is invalid: %v", err)
+	}
+}
 `
 	t, err := template.New("test").Parse(testTmpl)
 	if err != nil {
@@ -273,15 +282,18 @@ func NewGraph() *agora.Graph {
 	model_{{.Name}} := llm.NewOllamaLLM("http://localhost:11434/v1", "{{.Model}}") 
 	node_{{.Name}} := nodes.SimpleAgentNode(model_{{.Name}}, "{{.Instructions}}")
 	g.AddNode("{{.Name}}", node_{{.Name}})
+	{{else if eq .Type "router"}}
+	model_{{.Name}} := llm.NewOllamaLLM("http://localhost:11434/v1", "{{.Model}}")
+	g.AddNode("{{.Name}}", nodes.RouterNode(model_{{.Name}}, routerConfig_{{.Name}}),
+		agora.WithRoutes(nodes.RouteNames(routerConfig_{{.Name}}.Routes)...))
 	{{end}}
 	{{end}}
 
 	// --- Edges ---
 	{{range .Edges}}
 	{{if eq .To "END"}}
-	// Edge to END is implied by not having a next node in strict mode if strictly linear,
-	// but we can be explicit or just comment.
-	// g.AddEdge("{{.From}}", "") 
+	// An edge to END marks {{.From}} as terminal.
+	g.AddEdge("{{.From}}", agora.END)
 	{{else}}
 	g.AddEdge("{{.From}}", "{{.To}}")
 	{{end}}
@@ -289,6 +301,17 @@ func NewGraph() *agora.Graph {
 
 	return g
 }
+{{range .Nodes}}{{if eq .Type "router"}}
+// routerConfig_{{.Name}} declares the branches of the {{.Name}} router.
+var routerConfig_{{.Name}} = nodes.RouterConfig{
+	Instructions: {{printf "%q" .Instructions}},
+	Default:      {{printf "%q" .Default}},
+	Routes: []nodes.Route{
+		{{range .Routes}}{Name: {{printf "%q" .To}}, Description: {{printf "%q" .Description}}},
+		{{end}}
+	},
+}
+{{end}}{{end}}
 `
 	t, err := template.New("graph").Parse(tmplStr)
 	if err != nil {
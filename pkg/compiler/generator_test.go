@@ -1,8 +1,11 @@
 package compiler
 
 import (
+	"go/parser"
+	"go/token"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -51,3 +54,112 @@ edges:
 		}
 	}
 }
+
+func TestCompile_Router(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	outDir := filepath.Join(tmpDir, "build")
+
+	yamlContent := `
+project: router-test
+version: 0.1.0
+graph:
+  entry: triage
+  max_steps: 5
+nodes:
+  - name: triage
+    type: router
+    model: llama3
+    instructions: "Route billing questions to billing."
+    default: support
+    routes:
+      - to: billing
+        description: "Questions about invoices"
+      - to: support
+        description: "Everything else"
+  - name: billing
+    type: agent
+    model: llama3
+  - name: support
+    type: agent
+    model: llama3
+edges:
+  - from: billing
+    to: END
+  - from: support
+    to: END
+`
+	blueprintPath := filepath.Join(tmpDir, "agora.yaml")
+	if err := os.WriteFile(blueprintPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Execute
+	if err := Compile(blueprintPath, outDir); err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+
+	// Verify the generated graph is valid Go and wires the router
+	src, err := os.ReadFile(filepath.Join(outDir, "graph.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "graph.go", src, 0); err != nil {
+		t.Fatalf("generated graph.go does not parse: %v", err)
+	}
+	for _, want := range []string{
+		`nodes.RouterNode(model_triage, routerConfig_triage)`,
+		`{Name: "billing", Description: "Questions about invoices"}`,
+		`Default:      "support"`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("expected graph.go to contain %q", want)
+		}
+	}
+}
+
+func TestRenderBlueprint(t *testing.T) {
+	tmpDir := t.TempDir()
+	yamlContent := `
+project: render-test
+version: 0.1.0
+graph:
+  entry: triage
+  max_steps: 5
+nodes:
+  - name: triage
+    type: router
+    model: llama3
+    routes:
+      - to: billing
+      - to: END
+  - name: billing
+    type: agent
+    model: llama3
+edges:
+  - from: billing
+    to: END
+`
+	blueprintPath := filepath.Join(tmpDir, "agora.yaml")
+	if err := os.WriteFile(blueprintPath, []byte(yamlContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := RenderBlueprint(blueprintPath, "dot")
+	if err != nil {
+		t.Fatalf("RenderBlueprint failed: %v", err)
+	}
+	for _, want := range []string{
+		`"triage" -> "billing" [style=dashed, label="route"];`,
+		`"triage" -> "__end__" [style=dashed, label="route"];`,
+		`"billing" -> "__end__";`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	if _, err := RenderBlueprint(blueprintPath, "svg"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -63,6 +63,26 @@ func validate(bp *Blueprint) error {
 		}
 	}
 
+	// Validate Router Branches
+	for _, n := range bp.Nodes {
+		if n.Type != "router" {
+			continue
+		}
+		if len(n.Routes) == 0 {
+			return fmt.Errorf("router '%s' must declare at least one route", n.Name)
+		}
+		routeMap := make(map[string]bool)
+		for _, r := range n.Routes {
+			if !nodeMap[r.To] && r.To != "END" {
+				return fmt.Errorf("router '%s' route target '%s' does not exist", n.Name, r.To)
+			}
+			routeMap[r.To] = true
+		}
+		if n.Default != "" && !routeMap[n.Default] {
+			return fmt.Errorf("router '%s' default '%s' is not one of its routes", n.Name, n.Default)
+		}
+	}
+
 	// Validate Entry
 	if !nodeMap[bp.Graph.Entry] {
 		return fmt.Errorf("entry node '%s' does not exist", bp.Graph.Entry)
@@ -99,3 +99,31 @@ nodes:
 		t.Fatal("expected validation error (invalid node name), got nil")
 	}
 }
+
+func TestParseBlueprint_RouterUnknownRoute(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	// Router branch points at a node that does not exist
+	yamlContent := `
+project: bad-router
+graph:
+  entry: triage
+nodes:
+  - name: triage
+    type: router
+    routes:
+      - to: missing
+`
+	path := filepath.Join(tmpDir, "bad_router.yaml")
+	if err := os.WriteFile(path, []byte(yamlContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Execute
+	_, err := ParseBlueprint(path)
+
+	// Verify
+	if err == nil {
+		t.Fatal("expected validation error (unknown route target), got nil")
+	}
+}
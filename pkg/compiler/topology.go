@@ -0,0 +1,57 @@
+package compiler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/amangsingh/agora"
+)
+
+// BlueprintGraph builds the topology a blueprint describes, without any models
+// attached: every node is a stub that returns the state unchanged. It is meant
+// for validation and visualization, not execution.
+func BlueprintGraph(bp *Blueprint) *agora.Graph {
+	g := agora.NewGraph()
+	if bp.Graph.MaxSteps > 0 {
+		g.MaxSteps = bp.Graph.MaxSteps
+	}
+	g.SetEntry(bp.Graph.Entry)
+
+	stub := func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		return agora.NodeResult{State: s}, nil
+	}
+	for _, n := range bp.Nodes {
+		var opts []agora.NodeOption
+		if n.Type == "router" {
+			routes := make([]string, len(n.Routes))
+			for i, r := range n.Routes {
+				routes[i] = r.To
+			}
+			opts = append(opts, agora.WithRoutes(routes...))
+		}
+		g.AddNode(n.Name, stub, opts...)
+	}
+	for _, e := range bp.Edges {
+		g.AddEdge(e.From, e.To)
+	}
+	return g
+}
+
+// RenderBlueprint parses a blueprint and renders its topology in the given
+// format: "mermaid" or "dot".
+func RenderBlueprint(blueprintPath, format string) (string, error) {
+	bp, err := ParseBlueprint(blueprintPath)
+	if err != nil {
+		return "", err
+	}
+
+	g := BlueprintGraph(bp)
+	switch format {
+	case "mermaid":
+		return g.Mermaid(), nil
+	case "dot":
+		return g.DOT(), nil
+	default:
+		return "", fmt.Errorf("unknown format '%s': expected mermaid or dot", format)
+	}
+}
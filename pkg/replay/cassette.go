@@ -0,0 +1,334 @@
+// Package replay records LLM and tool calls to a cassette file and serves them
+// back offline, so a run can be reproduced exactly without a model server.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+// ErrDivergence is matched by the *DivergenceError returned when a replayed
+// run makes a call the cassette did not record.
+var ErrDivergence = errors.New("replay diverged from cassette")
+
+// Mode selects whether a cassette records live calls or replays recorded ones.
+type Mode int
+
+const (
+	// ModeRecord forwards calls to the wrapped LLM or tool and records them.
+	ModeRecord Mode = iota
+	// ModeReplay serves recorded responses and never calls the wrapped LLM or tool.
+	ModeReplay
+)
+
+// Call kinds stored in a cassette.
+const (
+	KindLLM  = "llm"
+	KindTool = "tool"
+)
+
+// Entry is one recorded call.
+type Entry struct {
+	Kind string `json:"kind"`
+	// Name is the model name for LLM calls and the tool name for tool calls.
+	Name string `json:"name"`
+	// Key is the SHA-256 of the normalized request.
+	Key string `json:"key"`
+	// Seq is the call's position among all calls of the run.
+	Seq      int             `json:"seq"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// DivergenceError describes a call that could not be served from the cassette.
+type DivergenceError struct {
+	Kind string
+	Name string
+	Key  string
+	// Expected is the next unplayed entry, if any.
+	Expected *Entry
+}
+
+func (e *DivergenceError) Error() string {
+	msg := fmt.Sprintf("%s: unrecorded %s call %q (key %s)", ErrDivergence, e.Kind, e.Name, shortKey(e.Key))
+	if e.Expected != nil {
+		msg += fmt.Sprintf("; next recorded call is %s %q #%d (key %s)", e.Expected.Kind, e.Expected.Name, e.Expected.Seq, shortKey(e.Expected.Key))
+	}
+	return msg
+}
+
+func (e *DivergenceError) Is(target error) bool {
+	return target == ErrDivergence
+}
+
+// cassetteFile is the on-disk format.
+type cassetteFile struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Cassette holds the recorded calls of a run. LLM calls and tool calls are
+// each replayed in the order they were recorded. It is safe for concurrent
+// use, so it works with ParallelNode and MapNode, as long as their branches
+// make their calls in a deterministic order.
+type Cassette struct {
+	mu      sync.Mutex
+	path    string
+	mode    Mode
+	entries []Entry
+	played  []bool
+}
+
+// NewCassette opens a cassette at path. In ModeReplay the file must exist and
+// is loaded immediately; in ModeRecord it is written by Close.
+func NewCassette(path string, mode Mode) (*Cassette, error) {
+	c := &Cassette{path: path, mode: mode}
+	if mode != ModeReplay {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var file cassetteFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette: %w", err)
+	}
+	c.entries = file.Entries
+	c.played = make([]bool, len(file.Entries))
+	return c, nil
+}
+
+// Entries returns a copy of the recorded calls in call order.
+func (c *Cassette) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Entry(nil), c.entries...)
+}
+
+// Close finishes the cassette. In ModeRecord it writes the file. In
+// ModeReplay it reports a divergence if recorded calls were never replayed.
+func (c *Cassette) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.mode == ModeReplay {
+		for i, played := range c.played {
+			if !played {
+				return fmt.Errorf("%w: %d recorded calls were not replayed, starting with %s %q #%d",
+					ErrDivergence, c.unplayed(), c.entries[i].Kind, c.entries[i].Name, c.entries[i].Seq)
+			}
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cassetteFile{Version: 1, Entries: c.entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// LLM wraps l so its calls go through the cassette.
+func (c *Cassette) LLM(l llm.LLM) llm.LLM {
+	return &cassetteLLM{cassette: c, next: l}
+}
+
+// Tool wraps t so its calls go through the cassette.
+func (c *Cassette) Tool(t agora.Tool) agora.Tool {
+	return &cassetteTool{cassette: c, next: t}
+}
+
+// Tools wraps every tool of a registry.
+func (c *Cassette) Tools(registry agora.ToolRegistry) agora.ToolRegistry {
+	wrapped := agora.NewToolRegistry()
+	for _, t := range registry {
+		wrapped.Register(c.Tool(t))
+	}
+	return wrapped
+}
+
+// record appends a live call.
+func (c *Cassette) record(kind, name, key string, request, response any, callErr error) error {
+	requestJSON, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", kind, err)
+	}
+	entry := Entry{Kind: kind, Name: name, Key: key, Request: requestJSON}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	} else if entry.Response, err = json.Marshal(response); err != nil {
+		return fmt.Errorf("failed to encode %s response: %w", kind, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.Seq = len(c.entries)
+	c.entries = append(c.entries, entry)
+	return nil
+}
+
+// replay returns the next unplayed entry of the given kind if it was
+// recorded for the same request. Anything else, including the same calls in
+// a different order, is a divergence.
+func (c *Cassette) replay(kind, name, key string) (Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	divergence := &DivergenceError{Kind: kind, Name: name, Key: key}
+	for i, e := range c.entries {
+		if c.played[i] || e.Kind != kind {
+			continue
+		}
+		if e.Key != key {
+			divergence.Expected = &e
+			return Entry{}, divergence
+		}
+		c.played[i] = true
+		return e, nil
+	}
+
+	// Nothing of this kind is left; point at whatever is.
+	for i, e := range c.entries {
+		if !c.played[i] {
+			divergence.Expected = &e
+			break
+		}
+	}
+	return Entry{}, divergence
+}
+
+func (c *Cassette) unplayed() int {
+	n := 0
+	for _, played := range c.played {
+		if !played {
+			n++
+		}
+	}
+	return n
+}
+
+type cassetteLLM struct {
+	cassette *Cassette
+	next     llm.LLM
+}
+
+// Invoke implements the LLM interface.
+func (l *cassetteLLM) Invoke(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+	key, err := RequestKey(request)
+	if err != nil {
+		return agora.ModelResponse{}, err
+	}
+
+	if l.cassette.mode == ModeReplay {
+		entry, err := l.cassette.replay(KindLLM, request.Model, key)
+		if err != nil {
+			return agora.ModelResponse{}, err
+		}
+		if entry.Error != "" {
+			return agora.ModelResponse{}, errors.New(entry.Error)
+		}
+		var response agora.ModelResponse
+		if err := json.Unmarshal(entry.Response, &response); err != nil {
+			return agora.ModelResponse{}, fmt.Errorf("failed to decode recorded response: %w", err)
+		}
+		return response, nil
+	}
+
+	response, callErr := l.next.Invoke(ctx, request)
+	if err := l.cassette.record(KindLLM, request.Model, key, request, response, callErr); err != nil {
+		return response, err
+	}
+	return response, callErr
+}
+
+type cassetteTool struct {
+	cassette *Cassette
+	next     agora.Tool
+}
+
+func (t *cassetteTool) Definition() agora.ToolDefinition {
+	return t.next.Definition()
+}
+
+// Execute runs or replays the tool. Replayed results are returned as
+// json.RawMessage, which marshals back to exactly what was recorded.
+func (t *cassetteTool) Execute(ctx context.Context, args map[string]interface{}) (any, error) {
+	name := t.next.Definition().Function.Name
+	key, err := hashJSON(struct {
+		Name string                 `json:"name"`
+		Args map[string]interface{} `json:"args"`
+	}{name, args})
+	if err != nil {
+		return nil, err
+	}
+
+	if t.cassette.mode == ModeReplay {
+		entry, err := t.cassette.replay(KindTool, name, key)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Error != "" {
+			return nil, errors.New(entry.Error)
+		}
+		// The cassette file is indented, so compact the result back to what
+		// the tool originally produced.
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, entry.Response); err != nil {
+			return nil, fmt.Errorf("failed to decode recorded tool result: %w", err)
+		}
+		return json.RawMessage(compact.Bytes()), nil
+	}
+
+	result, callErr := t.next.Execute(ctx, args)
+	if err := t.cassette.record(KindTool, name, key, args, result, callErr); err != nil {
+		return result, err
+	}
+	return result, callErr
+}
+
+// RequestKey hashes a normalized model request. It is the same key
+// llm.CachingLLM uses.
+func RequestKey(request agora.ModelRequest) (string, error) {
+	return llm.RequestKey(request)
+}
+
+// hashJSON hashes the canonical JSON encoding of v, with object keys sorted.
+func hashJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request for hashing: %w", err)
+	}
+	// Round-trip through a generic value so map keys are always sorted.
+	var generic any
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return "", fmt.Errorf("failed to normalize request: %w", err)
+	}
+	if data, err = json.Marshal(generic); err != nil {
+		return "", fmt.Errorf("failed to normalize request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func shortKey(key string) string {
+	if len(key) > 12 {
+		return key[:12]
+	}
+	return key
+}
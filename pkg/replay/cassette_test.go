@@ -0,0 +1,167 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/amangsingh/agora"
+)
+
+type fakeLLM struct {
+	calls int
+}
+
+func (f *fakeLLM) Invoke(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+	f.calls++
+	last := request.Messages[len(request.Messages)-1]
+	return agora.ModelResponse{
+		Model:   request.Model,
+		Choices: []agora.Choice{{Message: agora.ChatMessage{Role: "assistant", Content: "echo: " + last.Content}}},
+	}, nil
+}
+
+type fakeTool struct {
+	calls int
+}
+
+func (f *fakeTool) Definition() agora.ToolDefinition {
+	return agora.ToolDefinition{Type: "function", Function: agora.Function{Name: "lookup"}}
+}
+
+func (f *fakeTool) Execute(ctx context.Context, args map[string]interface{}) (any, error) {
+	f.calls++
+	return map[string]any{"answer": args["q"]}, nil
+}
+
+func request(content string) agora.ModelRequest {
+	return agora.ModelRequest{Model: "m", Messages: []agora.ChatMessage{{Role: "user", Content: content}}}
+}
+
+func TestCassette_RecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "run.json")
+
+	// 1. Record
+	rec, err := NewCassette(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, liveTool := &fakeLLM{}, &fakeTool{}
+	model, tool := rec.LLM(live), rec.Tool(liveTool)
+
+	if _, err := model.Invoke(ctx, request("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"q": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Invoke(ctx, request("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// 2. Replay without touching the live implementations
+	play, err := NewCassette(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline, offlineTool := &fakeLLM{}, &fakeTool{}
+	model, tool = play.LLM(offline), play.Tool(offlineTool)
+
+	resp, err := model.Invoke(ctx, request("a"))
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "echo: a" {
+		t.Errorf("unexpected replayed content %q", resp.Choices[0].Message.Content)
+	}
+	result, err := tool.Execute(ctx, map[string]interface{}{"q": "x"})
+	if err != nil {
+		t.Fatalf("tool replay failed: %v", err)
+	}
+	if string(result.(json.RawMessage)) != `{"answer":"x"}` {
+		t.Errorf("unexpected replayed tool result %s", result)
+	}
+
+	// The identical second request is served from the second recording.
+	if err := play.Close(); err == nil {
+		t.Error("expected Close to report the unplayed call")
+	}
+	if _, err := model.Invoke(ctx, request("a")); err != nil {
+		t.Fatalf("replay of repeated request failed: %v", err)
+	}
+	if err := play.Close(); err != nil {
+		t.Errorf("expected every call to be replayed, got %v", err)
+	}
+	if offline.calls != 0 || offlineTool.calls != 0 {
+		t.Errorf("replay must not call through, got %d LLM and %d tool calls", offline.calls, offlineTool.calls)
+	}
+}
+
+func TestCassette_Divergence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "run.json")
+
+	rec, _ := NewCassette(path, ModeRecord)
+	if _, err := rec.LLM(&fakeLLM{}).Invoke(ctx, request("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	play, err := NewCassette(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = play.LLM(&fakeLLM{}).Invoke(ctx, request("b"))
+	if !errors.Is(err, ErrDivergence) {
+		t.Fatalf("expected ErrDivergence, got %v", err)
+	}
+	var divergence *DivergenceError
+	if !errors.As(err, &divergence) || divergence.Expected == nil || divergence.Expected.Seq != 0 {
+		t.Errorf("expected divergence to point at the next recorded call, got %v", err)
+	}
+}
+
+func TestCassette_ReorderedCalls(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "run.json")
+
+	rec, _ := NewCassette(path, ModeRecord)
+	model := rec.LLM(&fakeLLM{})
+	for _, q := range []string{"a", "b"} {
+		if _, err := model.Invoke(ctx, request(q)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	play, err := NewCassette(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = play.LLM(&fakeLLM{}).Invoke(ctx, request("b"))
+	var divergence *DivergenceError
+	if !errors.As(err, &divergence) || divergence.Expected == nil || divergence.Expected.Seq != 0 {
+		t.Fatalf("expected the reordered call to diverge at #0, got %v", err)
+	}
+}
+
+func TestRequestKey_ToolOrder(t *testing.T) {
+	a := agora.ToolDefinition{Function: agora.Function{Name: "a"}}
+	b := agora.ToolDefinition{Function: agora.Function{Name: "b"}}
+
+	k1, _ := RequestKey(agora.ModelRequest{Tools: []agora.ToolDefinition{a, b}})
+	k2, _ := RequestKey(agora.ModelRequest{Tools: []agora.ToolDefinition{b, a}})
+	if k1 != k2 {
+		t.Error("expected tool order not to affect the request key")
+	}
+}
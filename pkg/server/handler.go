@@ -1,32 +1,52 @@
 package server
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/amangsingh/agora"
 	"github.com/amangsingh/agora/llm"
+	"github.com/amangsingh/agora/memory"
 	"github.com/amangsingh/agora/nodes"
 	"github.com/amangsingh/agora/pkg/storage"
+	"github.com/amangsingh/agora/redact"
 )
 
 type AgentHandler struct {
 	Repo *storage.Repository
+	// Prices is used to cost the LLM usage of each execution. Models missing
+	// from it are recorded with zero cost.
+	Prices agora.PriceTable
+	// ThreadHistory caps how many past messages of a thread a run sees.
+	// Zero loads the whole thread.
+	ThreadHistory int
+	// Redactor, when set, masks personal data and secrets in what is sent to
+	// the model. Pair it with Repository.SetRedactor to keep them out of the
+	// database too.
+	Redactor *redact.Redactor
 }
 
 type RunRequest struct {
 	Input string `json:"input"`
 	Model string `json:"model"` // Optional, default to internal config
+	// ThreadID continues a conversation: the thread's history is loaded
+	// before the run and its new turns saved after it.
+	ThreadID string `json:"thread_id,omitempty"`
 }
 
 type RunResponse struct {
 	ExecutionID string `json:"execution_id"`
 	Status      string `json:"status"`
 	Output      string `json:"output"`
+	ThreadID    string `json:"thread_id,omitempty"`
+	// Usage totals the LLM calls of the execution, including its cost.
+	Usage agora.UsageTotals `json:"usage"`
 }
 
 func (h *AgentHandler) HandleRun(w http.ResponseWriter, r *http.Request) {
@@ -60,29 +80,74 @@ func (h *AgentHandler) HandleRun(w http.ResponseWriter, r *http.Request) {
 
 	// 3. Execute Graph (Synchronous for now)
 	// In a real system, this might be async with a worker queue.
-	// We will construct a simple standard agent here.
-	ctx := r.Context()
+	// Every step is checkpointed so the run can later be forked.
+	ctx := agora.WithRunID(r.Context(), execID)
+	g := h.newGraph(req.Model)
+
+	initialState := &agora.ConversationState{
+		BaseState: agora.NewBaseState(),
+		Input:     req.Input,
+	}
+	var thread *memory.Thread
+	if req.ThreadID != "" {
+		// Threads belong to the key that started them.
+		thread = &memory.Thread{ID: threadKey(r.Context(), req.ThreadID), Store: h.Repo.Threads(), MaxHistory: h.ThreadHistory}
+		var err error
+		if initialState, err = thread.State(ctx, req.Input); err != nil {
+			h.Repo.UpdateExecution(execID, "failed", err.Error())
+			http.Error(w, "Failed to load thread: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	finalStateRaw, trace, err := g.ExecuteWithTrace(ctx, initialState)
+
+	// 4. Update Record and respond
+	resp := h.finishExecution(ctx, execID, finalStateRaw, trace, err)
+	if thread != nil {
+		resp.ThreadID = req.ThreadID
+		// Failed runs are not part of the conversation.
+		if err == nil {
+			if err := thread.Save(ctx, finalStateRaw.(*agora.ConversationState)); err != nil {
+				fmt.Printf("Failed to save thread: %v\n", err)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
 
+// threadKey scopes a client's thread ID to the API key of the request, so
+// keys cannot read each other's conversations.
+func threadKey(ctx context.Context, threadID string) string {
+	if key := APIKeyID(ctx); key != "" {
+		return key + "/" + threadID
+	}
+	return threadID
+}
+
+// newGraph builds the standard agent graph served by the API.
+func (h *AgentHandler) newGraph(modelName string) *agora.Graph {
 	// Default to a simple LLM based agent for demonstration/Phase 3
-	modelName := "llama3"
-	if req.Model != "" {
-		modelName = req.Model
+	if modelName == "" {
+		modelName = "llama3"
+	}
+	var model llm.LLM = llm.NewOllamaLLM("http://localhost:11434/v1", modelName)
+	if h.Redactor != nil {
+		model = llm.NewRedactingLLM(model, h.Redactor)
 	}
-	model := llm.NewOllamaLLM("http://localhost:11434/v1", modelName)
 	agent := nodes.SimpleAgentNode(model, "You are a helpful API agent.")
 
 	g := agora.NewGraph()
 	g.MaxSteps = 10
 	g.AddNode("agent", agent)
 	g.SetEntry("agent")
+	g.Checkpointer = h.Repo
+	return g
+}
 
-	initialState := &agora.ConversationState{
-		BaseState: agora.NewBaseState(),
-		Input:     req.Input,
-	}
-
-	finalStateRaw, err := g.Execute(ctx, initialState)
-
+// finishExecution saves the outcome and usage of a run and builds its response.
+func (h *AgentHandler) finishExecution(ctx context.Context, execID string, finalStateRaw agora.State, trace *agora.Trace, err error) RunResponse {
 	status := "completed"
 	output := ""
 	if err != nil {
@@ -93,28 +158,31 @@ func (h *AgentHandler) HandleRun(w http.ResponseWriter, r *http.Request) {
 		fs := finalStateRaw.(*agora.ConversationState)
 		if len(fs.History) > 0 {
 			lastMsg := fs.History[len(fs.History)-1]
-			output = lastMsg.Content
-			// Save history to DB
-			for _, msg := range fs.History {
-				_ = h.Repo.AppendMessage(execID, msg.Role, msg.Content)
+			output = lastMsg.Text()
+			// Save history to DB, images and files included
+			if err := h.Repo.SaveHistory(execID, fs.History); err != nil {
+				fmt.Printf("Failed to save history: %v\n", err)
 			}
 		}
 	}
 
-	// 4. Update Record
 	if err := h.Repo.UpdateExecution(execID, status, output); err != nil {
 		// Log error but we already processed
 		fmt.Printf("Failed to update execution: %v\n", err)
 	}
 
-	// 5. Response
-	resp := RunResponse{
+	// Usage is recorded for failed runs too: their calls were still billed.
+	usage := trace.UsageReport(h.Prices)
+	if err := h.Repo.SaveUsage(execID, APIKeyID(ctx), usage); err != nil {
+		fmt.Printf("Failed to save usage: %v\n", err)
+	}
+
+	return RunResponse{
 		ExecutionID: execID,
 		Status:      status,
 		Output:      output,
+		Usage:       usage.Total,
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
 }
 
 func (h *AgentHandler) HandleGetHistory(w http.ResponseWriter, r *http.Request) {
@@ -139,6 +207,182 @@ func (h *AgentHandler) HandleGetHistory(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(history)
 }
 
+// StepResponse is one checkpointed step of an execution.
+type StepResponse struct {
+	Step      int             `json:"step"`
+	Node      string          `json:"node,omitempty"`
+	Next      string          `json:"next"`
+	State     json.RawMessage `json:"state"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// HandleGetSteps lists the checkpointed steps of an execution.
+func (h *AgentHandler) HandleGetSteps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	execID := r.URL.Query().Get("execution_id")
+	if execID == "" {
+		http.Error(w, "Missing execution_id", http.StatusBadRequest)
+		return
+	}
+
+	checkpoints, err := h.Repo.ListCheckpoints(r.Context(), execID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve steps: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	steps := make([]StepResponse, len(checkpoints))
+	for i, cp := range checkpoints {
+		steps[i] = StepResponse{Step: cp.Step, Node: cp.Node, Next: cp.Next, State: cp.State, CreatedAt: cp.CreatedAt}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(steps)
+}
+
+type ForkRequest struct {
+	ExecutionID string `json:"execution_id"`
+	Step        int    `json:"step"`
+	// State optionally replaces the checkpointed state, e.g. a copy from
+	// /steps with a tool result edited.
+	State json.RawMessage `json:"state,omitempty"`
+	// Input optionally replaces the user input.
+	Input *string `json:"input,omitempty"`
+	Model string  `json:"model"` // Optional, default to internal config
+}
+
+// HandleFork creates a new execution from a step of an existing one and runs
+// it to completion. The original execution is left untouched.
+func (h *AgentHandler) HandleFork(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// 1. Strict JSON Parsing
+	var req ForkRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1048576))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ExecutionID == "" {
+		http.Error(w, "Missing execution_id", http.StatusBadRequest)
+		return
+	}
+
+	// 2. The step must exist before a new execution is recorded.
+	ctx := r.Context()
+	parent, err := h.Repo.GetExecution(req.ExecutionID)
+	if err != nil {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+	if _, err := h.Repo.LoadCheckpoint(ctx, req.ExecutionID, req.Step); err != nil {
+		if errors.Is(err, agora.ErrCheckpointNotFound) {
+			http.Error(w, "Step not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to load step: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	execID := generateID()
+	input := parent.Input
+	if req.Input != nil {
+		input = *req.Input
+	}
+	logEntry := storage.Execution{
+		ID:         execID,
+		Status:     "running",
+		Input:      input,
+		CreatedAt:  time.Now(),
+		ParentID:   req.ExecutionID,
+		ParentStep: req.Step,
+	}
+	if err := h.Repo.SaveExecution(logEntry); err != nil {
+		http.Error(w, "Failed to save execution: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 3. Fork with the requested edits.
+	g := h.newGraph(req.Model)
+	finalStateRaw, trace, err := g.ForkWithTrace(ctx, agora.ForkRequest{
+		RunID:    req.ExecutionID,
+		Step:     req.Step,
+		NewRunID: execID,
+		State:    &agora.ConversationState{},
+		Edit: func(s agora.State) error {
+			cs := s.(*agora.ConversationState)
+			if len(req.State) > 0 {
+				var edited agora.ConversationState
+				if err := json.Unmarshal(req.State, &edited); err != nil {
+					return fmt.Errorf("invalid state: %w", err)
+				}
+				*cs = edited
+			}
+			if cs.Values == nil {
+				cs.BaseState = agora.NewBaseState()
+			}
+			if req.Input != nil {
+				cs.Input = *req.Input
+			}
+			return nil
+		},
+	})
+
+	// 4. Update Record and respond
+	resp := h.finishExecution(ctx, execID, finalStateRaw, trace, err)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// HandleGetUsage reports the token usage and cost of an execution by node and model.
+func (h *AgentHandler) HandleGetUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	execID := r.URL.Query().Get("execution_id")
+	if execID == "" {
+		http.Error(w, "Missing execution_id", http.StatusBadRequest)
+		return
+	}
+	if _, err := h.Repo.GetExecution(execID); err != nil {
+		http.Error(w, "Execution not found", http.StatusNotFound)
+		return
+	}
+
+	usage, err := h.Repo.GetUsage(execID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
+
+// HandleGetKeyUsage reports the accumulated usage and cost of every API key.
+func (h *AgentHandler) HandleGetKeyUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	keys, err := h.Repo.UsageByAPIKey()
+	if err != nil {
+		http.Error(w, "Failed to retrieve usage: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
 func generateID() string {
 	b := make([]byte, 8)
 	rand.Read(b)
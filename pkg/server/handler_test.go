@@ -2,12 +2,14 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 
+	"github.com/amangsingh/agora"
 	"github.com/amangsingh/agora/pkg/storage"
 )
 
@@ -82,3 +84,165 @@ func TestHandler_Success(t *testing.T) {
 		t.Error("ExecutionID is empty")
 	}
 }
+
+func TestHandler_StepsAndFork(t *testing.T) {
+	repo, _ := storage.NewRepository(":memory:")
+	handler := &AgentHandler{Repo: repo}
+
+	// 1. Run once. Without a model server the run fails, but its initial
+	// state is still checkpointed.
+	req := httptest.NewRequest("POST", "/run", bytes.NewBufferString(`{"input": "original"}`))
+	w := httptest.NewRecorder()
+	handler.HandleRun(w, req)
+	var run RunResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &run); err != nil {
+		t.Fatalf("Failed to parse run response: %v", err)
+	}
+
+	// 2. List its steps.
+	req = httptest.NewRequest("GET", "/steps?execution_id="+run.ExecutionID, nil)
+	w = httptest.NewRecorder()
+	handler.HandleGetSteps(w, req)
+	var steps []StepResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &steps); err != nil {
+		t.Fatalf("Failed to parse steps: %v", err)
+	}
+	if len(steps) == 0 || steps[0].Step != 0 || steps[0].Next != "agent" {
+		t.Fatalf("Expected an initial step pointing at the agent, got %+v", steps)
+	}
+
+	// 3. Fork from step 0 with a new input.
+	body := `{"execution_id": "` + run.ExecutionID + `", "step": 0, "input": "edited"}`
+	req = httptest.NewRequest("POST", "/fork", bytes.NewBufferString(body))
+	w = httptest.NewRecorder()
+	handler.HandleFork(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	var fork RunResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &fork); err != nil {
+		t.Fatalf("Failed to parse fork response: %v", err)
+	}
+
+	exec, err := repo.GetExecution(fork.ExecutionID)
+	if err != nil {
+		t.Fatalf("GetExecution failed: %v", err)
+	}
+	if exec.ParentID != run.ExecutionID || exec.Input != "edited" {
+		t.Errorf("Expected fork of %s with edited input, got %+v", run.ExecutionID, exec)
+	}
+
+	// 4. Unknown steps are rejected.
+	body = `{"execution_id": "` + run.ExecutionID + `", "step": 99}`
+	req = httptest.NewRequest("POST", "/fork", bytes.NewBufferString(body))
+	w = httptest.NewRecorder()
+	handler.HandleFork(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown step, got %d", w.Code)
+	}
+}
+
+func TestHandler_Usage(t *testing.T) {
+	os.Setenv("AGORA_AUTH_TOKEN", "secret-token")
+	defer os.Unsetenv("AGORA_AUTH_TOKEN")
+
+	repo, _ := storage.NewRepository(":memory:")
+	handler := &AgentHandler{Repo: repo}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /run", handler.HandleRun)
+	mux.HandleFunc("GET /usage", handler.HandleGetUsage)
+	mux.HandleFunc("GET /usage/keys", handler.HandleGetKeyUsage)
+	protected := BearerAuth(mux)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer secret-token")
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, req)
+		return w
+	}
+
+	// 1. A run records usage against the calling key, even with no LLM calls.
+	var run RunResponse
+	if err := json.Unmarshal(do("POST", "/run", `{"input": "hi"}`).Body.Bytes(), &run); err != nil {
+		t.Fatalf("Failed to parse run response: %v", err)
+	}
+	report := agora.UsageReport{Entries: []agora.UsageEntry{
+		{Node: "agent", Model: "llama3", UsageTotals: agora.UsageTotals{Calls: 1, TotalTokens: 42, Cost: 0.5}},
+	}}
+	if err := repo.SaveUsage(run.ExecutionID, KeyID("secret-token"), report); err != nil {
+		t.Fatalf("SaveUsage failed: %v", err)
+	}
+
+	// 2. Per execution.
+	w := do("GET", "/usage?execution_id="+run.ExecutionID, "")
+	var usage storage.ExecutionUsage
+	if err := json.Unmarshal(w.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("Failed to parse usage: %v (%s)", err, w.Body.String())
+	}
+	if usage.Total.TotalTokens != 42 || usage.APIKeyID != KeyID("secret-token") {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+	if w := do("GET", "/usage?execution_id=missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown execution, got %d", w.Code)
+	}
+
+	// 3. Per API key.
+	var keys []storage.KeyUsage
+	if err := json.Unmarshal(do("GET", "/usage/keys", "").Body.Bytes(), &keys); err != nil {
+		t.Fatalf("Failed to parse key usage: %v", err)
+	}
+	if len(keys) != 1 || keys[0].Cost != 0.5 || keys[0].APIKeyID == "secret-token" {
+		t.Errorf("Unexpected key usage: %+v", keys)
+	}
+}
+
+func TestHandler_Thread(t *testing.T) {
+	os.Setenv("AGORA_AUTH_TOKEN", "secret-token")
+	defer os.Unsetenv("AGORA_AUTH_TOKEN")
+
+	repo, _ := storage.NewRepository(":memory:")
+	handler := &AgentHandler{Repo: repo}
+	protected := BearerAuth(http.HandlerFunc(handler.HandleRun))
+
+	// 1. An earlier turn of the thread, stored under the calling key.
+	threads := repo.Threads()
+	if err := threads.AppendThread(context.Background(), KeyID("secret-token")+"/t1",
+		agora.ChatMessage{Role: "user", Content: "My name is Ada."},
+		agora.ChatMessage{Role: "assistant", Content: "Hello Ada."},
+	); err != nil {
+		t.Fatalf("AppendThread failed: %v", err)
+	}
+
+	// 2. Continue it. Without a model server the run fails, but its initial
+	// state is checkpointed.
+	req := httptest.NewRequest("POST", "/run", bytes.NewBufferString(`{"input": "What is my name?", "thread_id": "t1"}`))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	w := httptest.NewRecorder()
+	protected.ServeHTTP(w, req)
+	var run RunResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &run); err != nil {
+		t.Fatalf("Failed to parse run response: %v (%s)", err, w.Body.String())
+	}
+	if run.ThreadID != "t1" {
+		t.Errorf("Expected thread t1 in response, got %q", run.ThreadID)
+	}
+
+	checkpoints, err := repo.ListCheckpoints(context.Background(), run.ExecutionID)
+	if err != nil || len(checkpoints) == 0 {
+		t.Fatalf("Expected checkpoints, got %v", err)
+	}
+	var state agora.ConversationState
+	if err := json.Unmarshal(checkpoints[0].State, &state); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+	if len(state.History) != 2 || state.History[0].Content != "My name is Ada." || state.Input != "What is my name?" {
+		t.Errorf("Expected the thread's history in the initial state, got %+v", state)
+	}
+
+	// 3. The failed run added nothing to the thread.
+	messages, _ := threads.LoadThread(context.Background(), KeyID("secret-token")+"/t1", 0)
+	if len(messages) != 2 {
+		t.Errorf("Expected the thread unchanged, got %d messages", len(messages))
+	}
+}
@@ -1,7 +1,10 @@
 package server
 
 import (
+	"context"
+	"crypto/sha256"
 	"crypto/subtle"
+	"encoding/hex"
 	"log"
 	"net/http"
 	"os"
@@ -53,6 +56,22 @@ func BearerAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), apiKeyIDKey{}, KeyID(parts[1]))
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+type apiKeyIDKey struct{}
+
+// KeyID identifies an API key without revealing it: the first 16 hex
+// characters of its SHA-256.
+func KeyID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// APIKeyID returns the ID of the key that authenticated the request, or "".
+func APIKeyID(ctx context.Context) string {
+	id, _ := ctx.Value(apiKeyIDKey{}).(string)
+	return id
+}
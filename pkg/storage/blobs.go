@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/amangsingh/agora"
+)
+
+// Images and files are kept out of the tables that reference them: each is
+// stored once in blobs, keyed by the SHA-256 of its content, and referenced
+// by hash from message_parts or, inside JSON such as checkpointed state, by
+// a blobRef string in place of its data: URL.
+
+// blobRef prefixes a blob hash standing in for a data: URL in stored JSON.
+const blobRef = "agora-blob:"
+
+// minExternalDataURL is the length from which data: URLs in stored JSON are
+// moved to blobs. Smaller ones cost less inline.
+const minExternalDataURL = 1024
+
+// execer is a *sql.DB or *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// SaveBlob stores data once and returns its hash.
+func (r *Repository) SaveBlob(ctx context.Context, mimeType string, data []byte) (string, error) {
+	return saveBlob(ctx, r.db, mimeType, data)
+}
+
+func saveBlob(ctx context.Context, ex execer, mimeType string, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	_, err := ex.ExecContext(ctx, `INSERT OR IGNORE INTO blobs (hash, mime_type, size, data, created_at) VALUES (?, ?, ?, ?, ?)`,
+		hash, mimeType, len(data), data, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to save blob: %w", err)
+	}
+	return hash, nil
+}
+
+// GetBlob returns a stored blob and its MIME type.
+func (r *Repository) GetBlob(ctx context.Context, hash string) (string, []byte, error) {
+	var mimeType string
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `SELECT mime_type, data FROM blobs WHERE hash = ?`, hash).Scan(&mimeType, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil, fmt.Errorf("blob %s not found", hash)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get blob: %w", err)
+	}
+	return mimeType, data, nil
+}
+
+// saveParts stores the parts of a message, their data as blobs.
+func (r *Repository) saveParts(ctx context.Context, ex execer, messageID int64, parts []agora.ContentPart) error {
+	for i, part := range parts {
+		var hash sql.NullString
+		if len(part.Data) > 0 {
+			h, err := saveBlob(ctx, ex, part.MIMEType, part.Data)
+			if err != nil {
+				return err
+			}
+			hash = sql.NullString{String: h, Valid: true}
+		}
+		_, err := ex.ExecContext(ctx, `INSERT INTO message_parts (message_id, position, type, text, url, blob_hash, mime_type, file_id, filename, detail)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			messageID, i, part.Type, r.redactText(part.Text), part.URL, hash, part.MIMEType, part.FileID, part.Filename, part.Detail)
+		if err != nil {
+			return fmt.Errorf("failed to save message part: %w", err)
+		}
+	}
+	return nil
+}
+
+// redactedFields are the JSON fields, matched case-insensitively, whose text
+// the repository's redactor applies to in stored JSON: what was said, not
+// roles, IDs or tool arguments, which a resumed run must get back unchanged.
+var redactedFields = map[string]bool{
+	"content":           true,
+	"reasoning_content": true,
+	"text":              true,
+	"input":             true,
+	"output":            true,
+}
+
+// encodeJSON prepares a JSON document for storage: large data: URLs move to
+// blobs and the text of redactedFields is redacted. Data URLs are never
+// redacted, since that would corrupt their base64. Keys, numbers and other
+// strings are kept exactly as they were.
+func (r *Repository) encodeJSON(ctx context.Context, ex execer, data []byte) ([]byte, error) {
+	if r.redact == nil && !bytes.Contains(data, []byte(`"data:`)) {
+		return data, nil
+	}
+	return mapJSONStrings(data, func(field, s string) (string, error) {
+		mimeType, decoded, ok := agora.ParseDataURL(s)
+		if !ok {
+			if redactedFields[strings.ToLower(field)] {
+				return r.redactText(s), nil
+			}
+			return s, nil
+		}
+		if len(s) < minExternalDataURL {
+			return s, nil
+		}
+		hash, err := saveBlob(ctx, ex, mimeType, decoded)
+		if err != nil {
+			return "", err
+		}
+		return blobRef + hash, nil
+	})
+}
+
+// decodeJSON reverses encodeJSON, putting blobs back as data: URLs.
+func (r *Repository) decodeJSON(ctx context.Context, data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(`"`+blobRef)) {
+		return data, nil
+	}
+	return mapJSONStrings(data, func(_, s string) (string, error) {
+		hash, ok := strings.CutPrefix(s, blobRef)
+		if !ok {
+			return s, nil
+		}
+		mimeType, blob, err := r.GetBlob(ctx, hash)
+		if err != nil {
+			return "", err
+		}
+		return agora.DataURL(mimeType, blob), nil
+	})
+}
+
+// mapJSONStrings applies f to every string value in a JSON document, along
+// with the name of the object field it belongs to. Strings in arrays belong
+// to the array's field.
+func mapJSONStrings(data []byte, f func(field, s string) (string, error)) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode stored JSON: %w", err)
+	}
+	v, err := mapValue("", v, f)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func mapValue(field string, v any, f func(field, s string) (string, error)) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return f(field, v)
+	case map[string]any:
+		for k, item := range v {
+			mapped, err := mapValue(k, item, f)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = mapped
+		}
+		return v, nil
+	case []any:
+		for i, item := range v {
+			mapped, err := mapValue(field, item, f)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = mapped
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
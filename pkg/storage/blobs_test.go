@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/redact"
+)
+
+func TestHistory_ContentParts(t *testing.T) {
+	repo, err := NewRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	if err := repo.SaveExecution(Execution{ID: "exec-1", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save execution: %v", err)
+	}
+
+	screenshot := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 1000)
+	history := []agora.ChatMessage{
+		agora.NewMultimodalMessage("user",
+			agora.TextPart("What changed?"),
+			agora.ImageDataPart("image/png", screenshot),
+			agora.ImageURLPart("https://example.com/before.png"),
+		),
+		agora.NewMultimodalMessage("user",
+			agora.TextPart("Same again"),
+			agora.ImageDataPart("image/png", screenshot),
+			agora.FileIDPart("file-123"),
+		),
+		{Role: "assistant", Content: "Nothing."},
+	}
+	if err := repo.SaveHistory("exec-1", history); err != nil {
+		t.Fatalf("SaveHistory failed: %v", err)
+	}
+
+	// 1. Parts come back whole, data included.
+	got, err := repo.GetHistory("exec-1")
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	if len(got) != 3 || got[0].Content != "What changed?" || got[2].Content != "Nothing." || got[2].Parts != nil {
+		t.Fatalf("Unexpected history: %+v", got)
+	}
+	if len(got[0].Parts) != 3 || !bytes.Equal(got[0].Parts[1].Data, screenshot) || got[0].Parts[1].MIMEType != "image/png" || got[0].Parts[2].URL != "https://example.com/before.png" {
+		t.Errorf("Unexpected parts: %+v", got[0].Parts)
+	}
+	if len(got[1].Parts) != 3 || got[1].Parts[2].FileID != "file-123" {
+		t.Errorf("Unexpected parts: %+v", got[1].Parts)
+	}
+
+	// 2. The screenshot is stored once, outside the messages table.
+	var blobs, largest int
+	repo.db.QueryRow(`SELECT COUNT(*) FROM blobs`).Scan(&blobs)
+	repo.db.QueryRow(`SELECT MAX(LENGTH(content)) FROM messages`).Scan(&largest)
+	if blobs != 1 || largest > 100 {
+		t.Errorf("Expected one blob and small messages, got %d blobs and content of %d bytes", blobs, largest)
+	}
+}
+
+func TestStoredJSON_Blobs(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	image := bytes.Repeat([]byte("pixel"), 500)
+	msg := agora.NewMultimodalMessage("user", agora.TextPart("see"), agora.ImageDataPart("image/png", image))
+
+	// 1. Checkpointed state keeps images as blobs and gets them back.
+	state, _ := json.Marshal(agora.ConversationState{History: []agora.ChatMessage{msg}})
+	if err := repo.SaveCheckpoint(ctx, agora.Checkpoint{RunID: "run-1", Step: 1, Node: "agent", State: state}); err != nil {
+		t.Fatalf("SaveCheckpoint failed: %v", err)
+	}
+	var stored string
+	repo.db.QueryRow(`SELECT state FROM checkpoints WHERE run_id = 'run-1'`).Scan(&stored)
+	if strings.Contains(stored, "data:image/png") || !strings.Contains(stored, blobRef) {
+		t.Errorf("Expected the image as a blob reference, got %.200s", stored)
+	}
+	cp, err := repo.LoadCheckpoint(ctx, "run-1", 1)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint failed: %v", err)
+	}
+	var restored agora.ConversationState
+	if err := json.Unmarshal(cp.State, &restored); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+	if len(restored.History) != 1 || !bytes.Equal(restored.History[0].Parts[1].Data, image) {
+		t.Errorf("Unexpected restored state: %+v", restored)
+	}
+
+	// 2. So do thread messages.
+	threads := repo.Threads()
+	if err := threads.AppendThread(ctx, "t1", msg); err != nil {
+		t.Fatalf("AppendThread failed: %v", err)
+	}
+	repo.db.QueryRow(`SELECT message FROM thread_messages WHERE thread_id = 't1'`).Scan(&stored)
+	if strings.Contains(stored, "data:image/png") {
+		t.Errorf("Expected the image as a blob reference, got %.200s", stored)
+	}
+	messages, err := threads.LoadThread(ctx, "t1", 0)
+	if err != nil {
+		t.Fatalf("LoadThread failed: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Text() != "see" || !bytes.Equal(messages[0].Parts[1].Data, image) {
+		t.Errorf("Unexpected thread: %+v", messages)
+	}
+}
+
+func TestStoredJSON_SmallDataURLNotRedacted(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	repo.SetRedactor(redact.New().Redact)
+
+	// Base64 that the phone rule would otherwise match, kept inline.
+	image, _ := base64.StdEncoding.DecodeString("iVBORw0KGgo/1234567890/A")
+	if url := agora.DataURL("image/png", image); !strings.Contains(url, "/1234567890/") {
+		t.Fatalf("Test image should encode to digits, got %s", url)
+	}
+	msg := agora.NewMultimodalMessage("user", agora.TextPart("mail ada@example.com"), agora.ImageDataPart("image/png", image))
+
+	threads := repo.Threads()
+	if err := threads.AppendThread(ctx, "t1", msg); err != nil {
+		t.Fatalf("AppendThread failed: %v", err)
+	}
+	messages, err := threads.LoadThread(ctx, "t1", 0)
+	if err != nil {
+		t.Fatalf("LoadThread failed: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Text() != "mail [EMAIL]" {
+		t.Fatalf("Unexpected thread: %+v", messages)
+	}
+	if p := messages[0].Parts[1]; !bytes.Equal(p.Data, image) || p.URL != "" {
+		t.Errorf("Expected the image intact, got %+v", p)
+	}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amangsingh/agora/llm"
+)
+
+// LLMCache is an llm.CacheStore backed by the repository's database.
+type LLMCache struct {
+	db *sql.DB
+}
+
+// LLMCache returns a response cache store sharing the repository's database.
+func (r *Repository) LLMCache() *LLMCache {
+	return &LLMCache{db: r.db}
+}
+
+// Get implements llm.CacheStore.
+func (c *LLMCache) Get(ctx context.Context, key string) (llm.CacheEntry, bool, error) {
+	var data string
+	err := c.db.QueryRowContext(ctx, `SELECT entry FROM llm_cache WHERE key = ?`, key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return llm.CacheEntry{}, false, nil
+	}
+	if err != nil {
+		return llm.CacheEntry{}, false, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	var entry llm.CacheEntry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		return llm.CacheEntry{}, false, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+	return entry, true, nil
+}
+
+// Set implements llm.CacheStore.
+func (c *LLMCache) Set(ctx context.Context, key string, entry llm.CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	var expiresAt sql.NullTime
+	if !entry.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: entry.ExpiresAt, Valid: true}
+	}
+
+	query := `INSERT OR REPLACE INTO llm_cache (key, entry, expires_at) VALUES (?, ?, ?)`
+	if _, err := c.db.ExecContext(ctx, query, key, string(data), expiresAt); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes expired entries and reports how many were removed.
+func (c *LLMCache) Prune(ctx context.Context) (int64, error) {
+	res, err := c.db.ExecContext(ctx, `DELETE FROM llm_cache WHERE expires_at IS NOT NULL AND expires_at < ?`, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune cache: %w", err)
+	}
+	return res.RowsAffected()
+}
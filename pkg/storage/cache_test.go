@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+func TestLLMCache(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	cache := repo.LLMCache()
+
+	if _, ok, err := cache.Get(ctx, "missing"); ok || err != nil {
+		t.Fatalf("Expected a clean miss, got %v, %v", ok, err)
+	}
+
+	entry := llm.CacheEntry{Response: agora.ModelResponse{Model: "m"}}
+	if err := cache.Set(ctx, "fresh", entry); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	entry.ExpiresAt = time.Now().Add(-time.Minute)
+	if err := cache.Set(ctx, "stale", entry); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	got, ok, err := cache.Get(ctx, "fresh")
+	if err != nil || !ok || got.Response.Model != "m" {
+		t.Errorf("Expected cached entry, got %+v, %v, %v", got, ok, err)
+	}
+
+	removed, err := cache.Prune(ctx)
+	if err != nil || removed != 1 {
+		t.Errorf("Expected 1 pruned entry, got %d, %v", removed, err)
+	}
+}
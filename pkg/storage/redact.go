@@ -0,0 +1,18 @@
+package storage
+
+// SetRedactor makes the repository persist text only after passing it
+// through redact, e.g. (*redact.Redactor).Redact. It covers execution inputs
+// and outputs, messages, and the message text, input and output in thread
+// messages and checkpointed state; IDs and tool arguments are kept so runs
+// can resume from them. Set it before the repository is used.
+func (r *Repository) SetRedactor(redact func(text string) string) {
+	r.redact = redact
+}
+
+// redactText applies the redactor, if any.
+func (r *Repository) redactText(text string) string {
+	if r.redact == nil {
+		return text
+	}
+	return r.redact(text)
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/redact"
+)
+
+func TestRepository_Redactor(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	repo.SetRedactor(redact.New().Redact)
+
+	// 1. Executions and messages.
+	if err := repo.SaveExecution(Execution{ID: "e1", Status: "running", Input: "I am ada@example.com", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SaveExecution failed: %v", err)
+	}
+	if err := repo.UpdateExecution("e1", "completed", "Card 4111 1111 1111 1111 noted"); err != nil {
+		t.Fatalf("UpdateExecution failed: %v", err)
+	}
+	if err := repo.AppendMessage("e1", "user", "I am ada@example.com"); err != nil {
+		t.Fatalf("AppendMessage failed: %v", err)
+	}
+	exec, _ := repo.GetExecution("e1")
+	history, _ := repo.GetHistory("e1")
+	if exec.Input != "I am [EMAIL]" || exec.Output != "Card [CREDIT_CARD] noted" || history[0].Content != "I am [EMAIL]" {
+		t.Errorf("Expected redacted records, got %+v and %+v", exec, history)
+	}
+
+	// 2. Checkpointed state keeps its structure and numbers.
+	state := []byte(`{"history":[{"role":"user","content":"ada@example.com"}],"Values":{"count":12345678901234567890}}`)
+	if err := repo.SaveCheckpoint(ctx, agora.Checkpoint{RunID: "e1", State: state, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SaveCheckpoint failed: %v", err)
+	}
+	cp, err := repo.LoadCheckpoint(ctx, "e1", 0)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint failed: %v", err)
+	}
+	if saved := string(cp.State); strings.Contains(saved, "ada@") || !strings.Contains(saved, "[EMAIL]") || !strings.Contains(saved, "12345678901234567890") {
+		t.Errorf("Unexpected checkpoint state %s", saved)
+	}
+
+	// 3. Threads.
+	if err := repo.Threads().AppendThread(ctx, "t1", agora.ChatMessage{Role: "user", Content: "token: sk-abcdefghijklmnopqrstuvwx1234"}); err != nil {
+		t.Fatalf("AppendThread failed: %v", err)
+	}
+	messages, _ := repo.Threads().LoadThread(ctx, "t1", 0)
+	if len(messages) != 1 || messages[0].Content != "token: [SECRET]" {
+		t.Errorf("Expected a redacted thread, got %+v", messages)
+	}
+}
+
+func TestRepository_RedactorKeepsIdentifiers(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	repo.SetRedactor(redact.New().Redact)
+
+	// IDs that look like a card and a phone number.
+	call := agora.ToolCall{ID: "4111111111111111", Type: "function"}
+	call.Function.Name = "lookup_order"
+	call.Function.Arguments = map[string]interface{}{"order_id": "5551234567"}
+	state := &agora.ConversationState{BaseState: agora.NewBaseState(), Input: "Where is order 5551234567? I am ada@example.com"}
+	state.History = []agora.ChatMessage{
+		{Role: "assistant", ToolCalls: []agora.ToolCall{call}},
+		{Role: "tool", ToolCallID: "4111111111111111", Content: "shipped to ada@example.com"},
+	}
+	state.Set("tool_calls", []agora.ToolCall{call})
+	state.Set("output", "mail ada@example.com")
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatalf("Failed to encode state: %v", err)
+	}
+	if err := repo.SaveCheckpoint(ctx, agora.Checkpoint{RunID: "r1", Step: 1, State: data, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("SaveCheckpoint failed: %v", err)
+	}
+
+	cp, err := repo.LoadCheckpoint(ctx, "r1", 1)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint failed: %v", err)
+	}
+	var restored agora.ConversationState
+	if err := json.Unmarshal(cp.State, &restored); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+	if strings.Contains(string(cp.State), "ada@") || restored.Input != "Where is order [PHONE]? I am [EMAIL]" || restored.Get("output") != "mail [EMAIL]" {
+		t.Errorf("Expected message text to be redacted, got %s", cp.State)
+	}
+	calls := restored.History[0].ToolCalls
+	if len(calls) != 1 || calls[0].ID != "4111111111111111" || calls[0].Function.Arguments["order_id"] != "5551234567" || restored.History[1].ToolCallID != "4111111111111111" {
+		t.Errorf("Expected tool calls intact, got %+v", restored.History)
+	}
+	if !strings.Contains(string(cp.State), `"order_id":"5551234567"`) {
+		t.Errorf("Expected tool_calls in values intact, got %s", cp.State)
+	}
+}
@@ -1,8 +1,11 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3" // Import sqlite3 driver
@@ -12,7 +15,8 @@ import (
 
 // Repository handles data persistence.
 type Repository struct {
-	db *sql.DB
+	db     *sql.DB
+	redact func(string) string
 }
 
 // Execution represents a run of an agent.
@@ -22,6 +26,10 @@ type Execution struct {
 	Input     string    `json:"input"`
 	Output    string    `json:"output"`
 	CreatedAt time.Time `json:"created_at"`
+	// ParentID and ParentStep are set when the execution was forked from a
+	// step of another execution.
+	ParentID   string `json:"parent_id,omitempty"`
+	ParentStep int    `json:"parent_step,omitempty"`
 }
 
 // NewRepository initializes the SQLite database.
@@ -59,6 +67,79 @@ func (r *Repository) migrate() error {
 			content TEXT,
 			FOREIGN KEY(execution_id) REFERENCES executions(id)
 		);`,
+		`CREATE TABLE IF NOT EXISTS checkpoints (
+			run_id TEXT,
+			step INTEGER,
+			node TEXT,
+			next TEXT,
+			state TEXT,
+			parent_run_id TEXT,
+			parent_step INTEGER,
+			created_at DATETIME,
+			PRIMARY KEY(run_id, step)
+		);`,
+		`CREATE TABLE IF NOT EXISTS llm_cache (
+			key TEXT PRIMARY KEY,
+			entry TEXT,
+			expires_at DATETIME
+		);`,
+		`CREATE TABLE IF NOT EXISTS vectors (
+			collection TEXT,
+			id TEXT,
+			content TEXT,
+			metadata TEXT,
+			vector BLOB,
+			PRIMARY KEY(collection, id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS vector_metadata (
+			collection TEXT,
+			id TEXT,
+			key TEXT,
+			value TEXT,
+			PRIMARY KEY(collection, id, key)
+		);`,
+		`CREATE INDEX IF NOT EXISTS vector_metadata_lookup ON vector_metadata (collection, key, value);`,
+		`CREATE TABLE IF NOT EXISTS usage (
+			execution_id TEXT,
+			api_key_id TEXT,
+			node TEXT,
+			model TEXT,
+			calls INTEGER,
+			prompt_tokens INTEGER,
+			completion_tokens INTEGER,
+			total_tokens INTEGER,
+			cost REAL,
+			created_at DATETIME,
+			PRIMARY KEY(execution_id, node, model)
+		);`,
+		`CREATE TABLE IF NOT EXISTS thread_messages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			thread_id TEXT,
+			message TEXT,
+			created_at DATETIME
+		);`,
+		`CREATE INDEX IF NOT EXISTS thread_messages_thread ON thread_messages (thread_id, id);`,
+		`CREATE TABLE IF NOT EXISTS blobs (
+			hash TEXT PRIMARY KEY,
+			mime_type TEXT,
+			size INTEGER,
+			data BLOB,
+			created_at DATETIME
+		);`,
+		`CREATE TABLE IF NOT EXISTS message_parts (
+			message_id INTEGER,
+			position INTEGER,
+			type TEXT,
+			text TEXT,
+			url TEXT,
+			blob_hash TEXT,
+			mime_type TEXT,
+			file_id TEXT,
+			filename TEXT,
+			detail TEXT,
+			PRIMARY KEY(message_id, position),
+			FOREIGN KEY(message_id) REFERENCES messages(id)
+		);`,
 	}
 
 	for _, q := range queries {
@@ -66,14 +147,26 @@ func (r *Repository) migrate() error {
 			return fmt.Errorf("executing query %q: %w", q, err)
 		}
 	}
+
+	// Columns added after the first release. Databases created before them
+	// are upgraded in place.
+	columns := []string{
+		`ALTER TABLE executions ADD COLUMN parent_id TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE executions ADD COLUMN parent_step INTEGER NOT NULL DEFAULT 0`,
+	}
+	for _, q := range columns {
+		if _, err := r.db.Exec(q); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("executing query %q: %w", q, err)
+		}
+	}
 	return nil
 }
 
 // SaveExecution saves the execution state.
 func (r *Repository) SaveExecution(exec Execution) error {
-	query := `INSERT INTO executions (id, status, input, output, created_at) VALUES (?, ?, ?, ?, ?)`
+	query := `INSERT INTO executions (id, status, input, output, created_at, parent_id, parent_step) VALUES (?, ?, ?, ?, ?, ?, ?)`
 	// Use Parameterized Query for security (Injection Scan)
-	_, err := r.db.Exec(query, exec.ID, exec.Status, exec.Input, exec.Output, exec.CreatedAt)
+	_, err := r.db.Exec(query, exec.ID, exec.Status, r.redactText(exec.Input), r.redactText(exec.Output), exec.CreatedAt, exec.ParentID, exec.ParentStep)
 	if err != nil {
 		return fmt.Errorf("failed to insert execution: %w", err)
 	}
@@ -81,7 +174,10 @@ func (r *Repository) SaveExecution(exec Execution) error {
 }
 
 // SaveHistory saves chat history for an execution using a transaction.
+// The parts of multimodal messages go to message_parts, their images and
+// files to blobs.
 func (r *Repository) SaveHistory(executionID string, messages []agora.ChatMessage) error {
+	ctx := context.Background()
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
@@ -94,18 +190,31 @@ func (r *Repository) SaveHistory(executionID string, messages []agora.ChatMessag
 	defer stmt.Close()
 
 	for _, msg := range messages {
-		if _, err := stmt.Exec(executionID, msg.Role, msg.Content); err != nil {
+		res, err := stmt.Exec(executionID, msg.Role, r.redactText(msg.Text()))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if len(msg.Parts) == 0 {
+			continue
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := r.saveParts(ctx, tx, id, msg.Parts); err != nil {
 			tx.Rollback()
 			return err
 		}
 	}
-	// ... existing methods ...
 	return tx.Commit()
 }
 
-// GetHistory retrieves chat history for an execution.
+// GetHistory retrieves chat history for an execution, including the parts
+// of multimodal messages.
 func (r *Repository) GetHistory(executionID string) ([]agora.ChatMessage, error) {
-	query := `SELECT role, content FROM messages WHERE execution_id = ? ORDER BY id ASC`
+	query := `SELECT id, role, content FROM messages WHERE execution_id = ? ORDER BY id ASC`
 	rows, err := r.db.Query(query, executionID)
 	if err != nil {
 		return nil, err
@@ -113,26 +222,163 @@ func (r *Repository) GetHistory(executionID string) ([]agora.ChatMessage, error)
 	defer rows.Close()
 
 	var history []agora.ChatMessage
+	index := map[int64]int{}
 	for rows.Next() {
+		var id int64
 		var msg agora.ChatMessage
-		if err := rows.Scan(&msg.Role, &msg.Content); err != nil {
+		if err := rows.Scan(&id, &msg.Role, &msg.Content); err != nil {
 			return nil, err
 		}
+		index[id] = len(history)
 		history = append(history, msg)
 	}
-	return history, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Attach the parts, with their blobs.
+	query = `SELECT p.message_id, p.type, p.text, p.url, p.mime_type, p.file_id, p.filename, p.detail, b.data
+		FROM message_parts p
+		JOIN messages m ON m.id = p.message_id
+		LEFT JOIN blobs b ON b.hash = p.blob_hash
+		WHERE m.execution_id = ? ORDER BY p.message_id, p.position`
+	partRows, err := r.db.Query(query, executionID)
+	if err != nil {
+		return nil, err
+	}
+	defer partRows.Close()
+	for partRows.Next() {
+		var id int64
+		var part agora.ContentPart
+		if err := partRows.Scan(&id, &part.Type, &part.Text, &part.URL, &part.MIMEType, &part.FileID, &part.Filename, &part.Detail, &part.Data); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			history[i].Parts = append(history[i].Parts, part)
+		}
+	}
+	return history, partRows.Err()
 }
 
 // AppendMessage saves a single message.
 func (r *Repository) AppendMessage(executionID, role, content string) error {
 	query := `INSERT INTO messages (execution_id, role, content) VALUES (?, ?, ?)`
-	_, err := r.db.Exec(query, executionID, role, content)
+	_, err := r.db.Exec(query, executionID, role, r.redactText(content))
 	return err
 }
 
 // Helper to update output/status
 func (r *Repository) UpdateExecution(id, status, output string) error {
 	query := `UPDATE executions SET status = ?, output = ? WHERE id = ?`
-	_, err := r.db.Exec(query, status, output, id)
+	_, err := r.db.Exec(query, status, r.redactText(output), id)
 	return err
 }
+
+// GetExecution retrieves a single execution, including its fork lineage.
+func (r *Repository) GetExecution(id string) (Execution, error) {
+	query := `SELECT id, status, input, output, created_at, parent_id, parent_step FROM executions WHERE id = ?`
+	var exec Execution
+	err := r.db.QueryRow(query, id).Scan(&exec.ID, &exec.Status, &exec.Input, &exec.Output, &exec.CreatedAt, &exec.ParentID, &exec.ParentStep)
+	if err != nil {
+		return Execution{}, fmt.Errorf("failed to get execution %s: %w", id, err)
+	}
+	return exec, nil
+}
+
+// ListForks returns the executions forked from the given execution.
+func (r *Repository) ListForks(parentID string) ([]Execution, error) {
+	query := `SELECT id, status, input, output, created_at, parent_id, parent_step FROM executions WHERE parent_id = ? ORDER BY created_at ASC`
+	rows, err := r.db.Query(query, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var forks []Execution
+	for rows.Next() {
+		var exec Execution
+		if err := rows.Scan(&exec.ID, &exec.Status, &exec.Input, &exec.Output, &exec.CreatedAt, &exec.ParentID, &exec.ParentStep); err != nil {
+			return nil, err
+		}
+		forks = append(forks, exec)
+	}
+	return forks, rows.Err()
+}
+
+// SaveCheckpoint implements agora.Checkpointer. A checkpoint for an existing
+// run and step replaces the old one.
+func (r *Repository) SaveCheckpoint(ctx context.Context, cp agora.Checkpoint) error {
+	query := `INSERT OR REPLACE INTO checkpoints (run_id, step, node, next, state, parent_run_id, parent_step, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	state, err := r.encodeJSON(ctx, r.db, cp.State)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, query, cp.RunID, cp.Step, cp.Node, cp.Next, string(state), cp.ParentRunID, cp.ParentStep, cp.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert checkpoint: %w", err)
+	}
+	return nil
+}
+
+// LoadCheckpoint implements agora.Checkpointer.
+func (r *Repository) LoadCheckpoint(ctx context.Context, runID string, step int) (agora.Checkpoint, error) {
+	query := `SELECT run_id, step, node, next, state, parent_run_id, parent_step, created_at FROM checkpoints WHERE run_id = ? AND step = ?`
+	cp, err := scanCheckpoint(r.db.QueryRowContext(ctx, query, runID, step))
+	if errors.Is(err, sql.ErrNoRows) {
+		return agora.Checkpoint{}, fmt.Errorf("run %s step %d: %w", runID, step, agora.ErrCheckpointNotFound)
+	}
+	if err != nil {
+		return agora.Checkpoint{}, err
+	}
+	if cp.State, err = r.decodeJSON(ctx, cp.State); err != nil {
+		return agora.Checkpoint{}, err
+	}
+	return cp, nil
+}
+
+// ListCheckpoints implements agora.Checkpointer.
+func (r *Repository) ListCheckpoints(ctx context.Context, runID string) ([]agora.Checkpoint, error) {
+	query := `SELECT run_id, step, node, next, state, parent_run_id, parent_step, created_at FROM checkpoints WHERE run_id = ? ORDER BY step ASC`
+	rows, err := r.db.QueryContext(ctx, query, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkpoints []agora.Checkpoint
+	for rows.Next() {
+		cp, err := scanCheckpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Blobs are read once the rows are done with.
+	for i := range checkpoints {
+		if checkpoints[i].State, err = r.decodeJSON(ctx, checkpoints[i].State); err != nil {
+			return nil, err
+		}
+	}
+	return checkpoints, nil
+}
+
+// scanCheckpoint reads a checkpoint row from either a *sql.Row or *sql.Rows.
+func scanCheckpoint(row interface{ Scan(dest ...any) error }) (agora.Checkpoint, error) {
+	var cp agora.Checkpoint
+	var state string
+	var parentRunID sql.NullString
+	var parentStep sql.NullInt64
+	if err := row.Scan(&cp.RunID, &cp.Step, &cp.Node, &cp.Next, &state, &parentRunID, &parentStep, &cp.CreatedAt); err != nil {
+		return agora.Checkpoint{}, err
+	}
+	cp.State = []byte(state)
+	cp.ParentRunID = parentRunID.String
+	cp.ParentStep = int(parentStep.Int64)
+	return cp, nil
+}
@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"context"
+	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -74,3 +77,44 @@ func TestHistoryOperations(t *testing.T) {
 		t.Errorf("Content mismatch: %s", retrieved[0].Content)
 	}
 }
+
+func TestCheckpointsAndLineage(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "agora.db")
+	repo, err := NewRepository(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+
+	// 1. Checkpoints round-trip and list in step order.
+	for _, step := range []int{1, 0} {
+		cp := agora.Checkpoint{RunID: "run-1", Step: step, Next: "agent", State: []byte(`{"Input":"hi"}`), CreatedAt: time.Now()}
+		if err := repo.SaveCheckpoint(ctx, cp); err != nil {
+			t.Fatalf("SaveCheckpoint failed: %v", err)
+		}
+	}
+	checkpoints, err := repo.ListCheckpoints(ctx, "run-1")
+	if err != nil || len(checkpoints) != 2 || checkpoints[0].Step != 0 {
+		t.Fatalf("Expected 2 ordered checkpoints, got %+v (%v)", checkpoints, err)
+	}
+	if string(checkpoints[1].State) != `{"Input":"hi"}` {
+		t.Errorf("Unexpected state %s", checkpoints[1].State)
+	}
+	if _, err := repo.LoadCheckpoint(ctx, "run-1", 7); !errors.Is(err, agora.ErrCheckpointNotFound) {
+		t.Errorf("Expected ErrCheckpointNotFound, got %v", err)
+	}
+
+	// 2. Lineage is stored on executions.
+	if err := repo.SaveExecution(Execution{ID: "run-2", Status: "running", CreatedAt: time.Now(), ParentID: "run-1", ParentStep: 1}); err != nil {
+		t.Fatalf("SaveExecution failed: %v", err)
+	}
+	forks, err := repo.ListForks("run-1")
+	if err != nil || len(forks) != 1 || forks[0].ParentStep != 1 {
+		t.Fatalf("Expected one fork at step 1, got %+v (%v)", forks, err)
+	}
+
+	// 3. Re-opening an existing database must not fail on the added columns.
+	if _, err := NewRepository(dbPath); err != nil {
+		t.Fatalf("Reopening failed: %v", err)
+	}
+}
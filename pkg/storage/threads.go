@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/amangsingh/agora"
+)
+
+// ThreadStore is a memory.ThreadStore backed by the repository's database.
+// Messages are stored whole, tool calls included, and redacted like the
+// repository's other text. Their images and files are stored as blobs.
+type ThreadStore struct {
+	repo *Repository
+}
+
+// Threads returns the store of conversation threads sharing the
+// repository's database.
+func (r *Repository) Threads() *ThreadStore {
+	return &ThreadStore{repo: r}
+}
+
+// LoadThread implements memory.ThreadStore.
+func (s *ThreadStore) LoadThread(ctx context.Context, threadID string, limit int) ([]agora.ChatMessage, error) {
+	if limit <= 0 {
+		limit = -1 // SQLite reads a negative limit as none
+	}
+	// The newest messages are selected, then put back in order.
+	rows, err := s.repo.db.QueryContext(ctx, `SELECT message FROM (
+			SELECT id, message FROM thread_messages WHERE thread_id = ? ORDER BY id DESC LIMIT ?
+		) ORDER BY id ASC`, threadID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load thread: %w", err)
+	}
+	defer rows.Close()
+
+	var stored []string
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		stored = append(stored, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Blobs are read once the rows are done with.
+	var messages []agora.ChatMessage
+	for _, data := range stored {
+		decoded, err := s.repo.decodeJSON(ctx, []byte(data))
+		if err != nil {
+			return nil, err
+		}
+		var msg agora.ChatMessage
+		if err := json.Unmarshal(decoded, &msg); err != nil {
+			return nil, fmt.Errorf("failed to decode thread message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// AppendThread implements memory.ThreadStore. The messages are added in one
+// transaction.
+func (s *ThreadStore) AppendThread(ctx context.Context, threadID string, messages ...agora.ChatMessage) error {
+	tx, err := s.repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, msg := range messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to encode thread message: %w", err)
+		}
+		if data, err = s.repo.encodeJSON(ctx, tx, data); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO thread_messages (thread_id, message, created_at) VALUES (?, ?, ?)`, threadID, string(data), now); err != nil {
+			return fmt.Errorf("failed to save thread message: %w", err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/memory"
+)
+
+func TestThreadStore(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	var store memory.ThreadStore = repo.Threads()
+
+	call := agora.ToolCall{ID: "call-1", Type: "function"}
+	call.Function.Name = "lookup"
+	call.Function.Arguments = map[string]interface{}{"q": "x"}
+	if err := store.AppendThread(ctx, "t1",
+		agora.ChatMessage{Role: "user", Content: "hi"},
+		agora.ChatMessage{Role: "assistant", ToolCalls: []agora.ToolCall{call}},
+		agora.ChatMessage{Role: "tool", Content: "found", ToolCallID: "call-1"},
+	); err != nil {
+		t.Fatalf("AppendThread failed: %v", err)
+	}
+	if err := store.AppendThread(ctx, "t2", agora.ChatMessage{Role: "user", Content: "other"}); err != nil {
+		t.Fatalf("AppendThread failed: %v", err)
+	}
+	if err := store.AppendThread(ctx, "t1", agora.ChatMessage{Role: "assistant", Content: "done"}); err != nil {
+		t.Fatalf("AppendThread failed: %v", err)
+	}
+
+	// 1. Whole thread, in order, with tool calls intact.
+	messages, err := store.LoadThread(ctx, "t1", 0)
+	if err != nil {
+		t.Fatalf("LoadThread failed: %v", err)
+	}
+	if len(messages) != 4 || messages[0].Content != "hi" || messages[3].Content != "done" {
+		t.Fatalf("Unexpected thread: %+v", messages)
+	}
+	if len(messages[1].ToolCalls) != 1 || messages[1].ToolCalls[0].Function.Name != "lookup" || messages[2].ToolCallID != "call-1" {
+		t.Errorf("Tool calls were not preserved: %+v", messages[1:3])
+	}
+
+	// 2. A limit keeps the newest messages.
+	messages, err = store.LoadThread(ctx, "t1", 2)
+	if err != nil {
+		t.Fatalf("LoadThread failed: %v", err)
+	}
+	if len(messages) != 2 || messages[0].Role != "tool" || messages[1].Content != "done" {
+		t.Errorf("Unexpected limited thread: %+v", messages)
+	}
+
+	// 3. Unknown threads are empty.
+	messages, err = store.LoadThread(ctx, "missing", 0)
+	if err != nil || len(messages) != 0 {
+		t.Errorf("Expected an empty thread, got %+v, %v", messages, err)
+	}
+}
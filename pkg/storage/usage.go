@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/amangsingh/agora"
+)
+
+// ExecutionUsage is the recorded LLM usage of one execution.
+type ExecutionUsage struct {
+	ExecutionID string             `json:"execution_id"`
+	APIKeyID    string             `json:"api_key_id,omitempty"`
+	Total       agora.UsageTotals  `json:"total"`
+	Entries     []agora.UsageEntry `json:"entries"`
+}
+
+// KeyUsage is the usage accumulated by one API key.
+type KeyUsage struct {
+	APIKeyID   string `json:"api_key_id"`
+	Executions int    `json:"executions"`
+	agora.UsageTotals
+}
+
+// SaveUsage records the usage report of an execution, attributed to the API
+// key that started it. Saving again replaces the previous figures.
+func (r *Repository) SaveUsage(executionID, apiKeyID string, report agora.UsageReport) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM usage WHERE execution_id = ?`, executionID); err != nil {
+		return fmt.Errorf("failed to clear usage: %w", err)
+	}
+	query := `INSERT INTO usage (execution_id, api_key_id, node, model, calls, prompt_tokens, completion_tokens, total_tokens, cost, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+	for _, e := range report.Entries {
+		if _, err := tx.Exec(query, executionID, apiKeyID, e.Node, e.Model, e.Calls, e.PromptTokens, e.CompletionTokens, e.TotalTokens, e.Cost, now); err != nil {
+			return fmt.Errorf("failed to save usage: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetUsage returns the usage of an execution, broken down by node and model.
+// An execution that made no LLM calls has zero totals.
+func (r *Repository) GetUsage(executionID string) (ExecutionUsage, error) {
+	query := `SELECT api_key_id, node, model, calls, prompt_tokens, completion_tokens, total_tokens, cost FROM usage WHERE execution_id = ? ORDER BY node, model`
+	rows, err := r.db.Query(query, executionID)
+	if err != nil {
+		return ExecutionUsage{}, err
+	}
+	defer rows.Close()
+
+	usage := ExecutionUsage{ExecutionID: executionID, Entries: []agora.UsageEntry{}}
+	for rows.Next() {
+		var e agora.UsageEntry
+		if err := rows.Scan(&usage.APIKeyID, &e.Node, &e.Model, &e.Calls, &e.PromptTokens, &e.CompletionTokens, &e.TotalTokens, &e.Cost); err != nil {
+			return ExecutionUsage{}, err
+		}
+		usage.Entries = append(usage.Entries, e)
+		usage.Total = usage.Total.Add(e.UsageTotals)
+	}
+	return usage, rows.Err()
+}
+
+// UsageByAPIKey sums usage per API key, most expensive first.
+func (r *Repository) UsageByAPIKey() ([]KeyUsage, error) {
+	query := `SELECT api_key_id, COUNT(DISTINCT execution_id), SUM(calls), SUM(prompt_tokens), SUM(completion_tokens), SUM(total_tokens), SUM(cost)
+		FROM usage GROUP BY api_key_id ORDER BY SUM(cost) DESC, api_key_id`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []KeyUsage{}
+	for rows.Next() {
+		var k KeyUsage
+		if err := rows.Scan(&k.APIKeyID, &k.Executions, &k.Calls, &k.PromptTokens, &k.CompletionTokens, &k.TotalTokens, &k.Cost); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+)
+
+func TestUsageAccounting(t *testing.T) {
+	repo, err := NewRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+
+	report := func(cost float64) agora.UsageReport {
+		return agora.UsageReport{Entries: []agora.UsageEntry{
+			{Node: "agent", Model: "gpt-4o", UsageTotals: agora.UsageTotals{Calls: 2, PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15, Cost: cost}},
+			{Node: "tools", Model: "gpt-4o", UsageTotals: agora.UsageTotals{Calls: 1, TotalTokens: 5, Cost: cost}},
+		}}
+	}
+	for _, id := range []string{"run-1", "run-2", "run-3"} {
+		if err := repo.SaveExecution(Execution{ID: id, Status: "completed", CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("SaveExecution failed: %v", err)
+		}
+	}
+
+	// 1. Per execution, saving again replaces the figures.
+	if err := repo.SaveUsage("run-1", "key-a", report(9)); err != nil {
+		t.Fatalf("SaveUsage failed: %v", err)
+	}
+	if err := repo.SaveUsage("run-1", "key-a", report(1)); err != nil {
+		t.Fatalf("SaveUsage failed: %v", err)
+	}
+	usage, err := repo.GetUsage("run-1")
+	if err != nil {
+		t.Fatalf("GetUsage failed: %v", err)
+	}
+	if usage.APIKeyID != "key-a" || len(usage.Entries) != 2 || usage.Total.Calls != 3 || usage.Total.Cost != 2 {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+
+	// 2. Per API key, most expensive first.
+	repo.SaveUsage("run-2", "key-a", report(1))
+	repo.SaveUsage("run-3", "key-b", report(5))
+	keys, err := repo.UsageByAPIKey()
+	if err != nil {
+		t.Fatalf("UsageByAPIKey failed: %v", err)
+	}
+	if len(keys) != 2 || keys[0].APIKeyID != "key-b" || keys[1].Executions != 2 || keys[1].Cost != 4 {
+		t.Errorf("Unexpected key usage: %+v", keys)
+	}
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/amangsingh/agora/vectorstore"
+)
+
+// VectorStore is a vectorstore.VectorStore backed by the repository's
+// database. Metadata is indexed for filtering; similarity is computed in
+// process over the matching documents.
+type VectorStore struct {
+	db         *sql.DB
+	collection string
+	metric     vectorstore.Metric
+}
+
+// VectorStore returns the named collection of documents. Collections share
+// the database but never each other's documents.
+func (r *Repository) VectorStore(collection string, metric vectorstore.Metric) *VectorStore {
+	return &VectorStore{db: r.db, collection: collection, metric: metric}
+}
+
+// Upsert implements vectorstore.VectorStore. All vectors in a collection
+// must have the same dimensions.
+func (s *VectorStore) Upsert(ctx context.Context, docs ...vectorstore.Document) error {
+	dims, err := s.dimensions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if dims, err = vectorstore.CheckDimensions(dims, doc.Vector); err != nil {
+			return fmt.Errorf("document %s: %w", doc.ID, err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, doc := range docs {
+		metadata, err := json.Marshal(doc.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to encode metadata of %s: %w", doc.ID, err)
+		}
+		query := `INSERT OR REPLACE INTO vectors (collection, id, content, metadata, vector) VALUES (?, ?, ?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, s.collection, doc.ID, doc.Content, string(metadata), encodeVector(doc.Vector)); err != nil {
+			return fmt.Errorf("failed to save document %s: %w", doc.ID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM vector_metadata WHERE collection = ? AND id = ?`, s.collection, doc.ID); err != nil {
+			return fmt.Errorf("failed to save document %s: %w", doc.ID, err)
+		}
+		for k, v := range doc.Metadata {
+			query := `INSERT INTO vector_metadata (collection, id, key, value) VALUES (?, ?, ?, ?)`
+			if _, err := tx.ExecContext(ctx, query, s.collection, doc.ID, k, v); err != nil {
+				return fmt.Errorf("failed to save document %s: %w", doc.ID, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Delete implements vectorstore.VectorStore.
+func (s *VectorStore) Delete(ctx context.Context, ids ...string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		for _, table := range []string{"vectors", "vector_metadata"} {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE collection = ? AND id = ?`, s.collection, id); err != nil {
+				return fmt.Errorf("failed to delete document %s: %w", id, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Search implements vectorstore.VectorStore.
+func (s *VectorStore) Search(ctx context.Context, query []float32, k int, filter vectorstore.Filter) ([]vectorstore.Result, error) {
+	// 1. Narrow down by metadata in SQL, one condition per filter key.
+	sqlQuery := `SELECT id, content, metadata, vector FROM vectors v WHERE collection = ?`
+	args := []any{s.collection}
+	keys := make([]string, 0, len(filter))
+	for key := range filter {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var conditions strings.Builder
+	for _, key := range keys {
+		conditions.WriteString(` AND EXISTS (SELECT 1 FROM vector_metadata m WHERE m.collection = v.collection AND m.id = v.id AND m.key = ? AND m.value = ?)`)
+		args = append(args, key, filter[key])
+	}
+
+	rows, err := s.db.QueryContext(ctx, sqlQuery+conditions.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+	defer rows.Close()
+
+	// 2. Score every candidate.
+	var results []vectorstore.Result
+	for rows.Next() {
+		var doc vectorstore.Document
+		var metadata string
+		var vector []byte
+		if err := rows.Scan(&doc.ID, &doc.Content, &metadata, &vector); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(metadata), &doc.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata of %s: %w", doc.ID, err)
+		}
+		doc.Vector = decodeVector(vector)
+		if len(doc.Vector) != len(query) {
+			return nil, fmt.Errorf("query has %d dimensions, expected %d", len(query), len(doc.Vector))
+		}
+		results = append(results, vectorstore.Result{Document: doc, Score: s.metric.Similarity(query, doc.Vector)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return vectorstore.Rank(results, k), nil
+}
+
+// dimensions returns the vector length used by the collection, or 0 when it is empty.
+func (s *VectorStore) dimensions(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT length(vector) FROM vectors WHERE collection = ? LIMIT 1`, s.collection).Scan(&n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read collection dimensions: %w", err)
+	}
+	return n / 4, nil
+}
+
+// encodeVector packs a vector as little-endian float32s.
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
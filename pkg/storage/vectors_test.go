@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amangsingh/agora/vectorstore"
+)
+
+func TestVectorStore(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewRepository(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+
+	// A store is used through the interface like any other.
+	var store vectorstore.VectorStore = repo.VectorStore("docs", vectorstore.DotProduct)
+	other := repo.VectorStore("other", vectorstore.Cosine)
+
+	docs := []vectorstore.Document{
+		{ID: "a", Content: "alpha", Vector: []float32{1, 0, 0}, Metadata: map[string]string{"source": "wiki", "lang": "en"}},
+		{ID: "b", Content: "beta", Vector: []float32{2, 1, 0}, Metadata: map[string]string{"source": "wiki", "lang": "de"}},
+		{ID: "c", Content: "gamma", Vector: []float32{0, 0, 5}, Metadata: map[string]string{"source": "blog", "lang": "en"}},
+	}
+	if err := store.Upsert(ctx, docs...); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if err := other.Upsert(ctx, vectorstore.Document{ID: "a", Vector: []float32{1, 1}}); err != nil {
+		t.Fatalf("Collections should not share dimensions: %v", err)
+	}
+	if err := store.Upsert(ctx, vectorstore.Document{ID: "d", Vector: []float32{1}}); err == nil {
+		t.Error("Expected mismatched dimensions to be rejected")
+	}
+
+	// 1. Dot product rewards the longer vector.
+	results, err := store.Search(ctx, []float32{1, 0, 0}, 2, nil)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "b" || results[0].Score != 2 || results[0].Metadata["lang"] != "de" {
+		t.Errorf("Unexpected results: %+v", results)
+	}
+
+	// 2. Every filter key must match.
+	results, _ = store.Search(ctx, []float32{1, 1, 1}, 0, vectorstore.Filter{"source": "wiki", "lang": "en"})
+	if len(results) != 1 || results[0].ID != "a" || results[0].Vector[0] != 1 {
+		t.Errorf("Expected only a, got %+v", results)
+	}
+
+	// 3. Upserting replaces metadata; deleting removes the document.
+	store.Upsert(ctx, vectorstore.Document{ID: "a", Content: "alpha", Vector: []float32{1, 0, 0}, Metadata: map[string]string{"source": "blog"}})
+	store.Delete(ctx, "c")
+	results, _ = store.Search(ctx, []float32{1, 0, 0}, 0, vectorstore.Filter{"source": "blog"})
+	if len(results) != 1 || results[0].ID != "a" {
+		t.Errorf("Expected only the re-tagged a, got %+v", results)
+	}
+}
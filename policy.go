@@ -0,0 +1,208 @@
+// in agora/policy.go
+
+package agora
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// StatusError is returned by Run when the model server answers with a non-200 status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received non-200 status: %d - %s", e.StatusCode, e.Body)
+}
+
+// IsRetryable is the default retry classification. It treats rate limiting,
+// server-side failures, timeouts and network errors as transient, and
+// everything else (including cancellation) as permanent.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusRequestTimeout ||
+			statusErr.StatusCode == http.StatusTooManyRequests ||
+			statusErr.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// NodeConfig holds the per-node runtime policy configured at AddNode time.
+type NodeConfig struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	// Backoff returns how long to wait before the given retry (1 for the first retry).
+	Backoff func(retry int) time.Duration
+	// Retryable decides which errors are worth retrying. Defaults to IsRetryable.
+	Retryable func(err error) bool
+	// Timeout bounds each attempt through a derived context. Zero means no timeout.
+	Timeout time.Duration
+	// Fallback is the node to route to when every attempt fails. The error is
+	// stored in state under "error" and the failing node under "error_node",
+	// instead of failing the whole run.
+	Fallback string
+	// Routes declares the nodes this node may jump to through NodeResult.NextNode,
+	// so validation and visualization can see them.
+	Routes []string
+	// SubGraph is the graph this node runs, if any, so renderers can draw it
+	// as a nested cluster.
+	SubGraph *Graph
+}
+
+// NodeOption configures a node's policy at AddNode time.
+type NodeOption func(*NodeConfig)
+
+// WithRetry retries a failing node up to maxAttempts times in total. The state
+// is restored from a pre-step DeepCopy before every retry. A nil backoff
+// defaults to ExponentialBackoff(200ms, 5s).
+func WithRetry(maxAttempts int, backoff func(retry int) time.Duration) NodeOption {
+	return func(c *NodeConfig) {
+		c.MaxAttempts = maxAttempts
+		c.Backoff = backoff
+	}
+}
+
+// WithRetryIf overrides which errors are retried.
+func WithRetryIf(retryable func(err error) bool) NodeOption {
+	return func(c *NodeConfig) {
+		c.Retryable = retryable
+	}
+}
+
+// WithTimeout bounds each attempt of the node.
+func WithTimeout(d time.Duration) NodeOption {
+	return func(c *NodeConfig) {
+		c.Timeout = d
+	}
+}
+
+// WithFallback routes to the given node when the node fails for good.
+func WithFallback(node string) NodeOption {
+	return func(c *NodeConfig) {
+		c.Fallback = node
+	}
+}
+
+// WithRoutes declares the nodes a node may jump to via NodeResult.NextNode.
+// Include END if the node can finish the run.
+func WithRoutes(targets ...string) NodeOption {
+	return func(c *NodeConfig) {
+		c.Routes = targets
+	}
+}
+
+// WithSubGraph records that a node runs the given graph (e.g. through
+// nodes.SubGraphNode), so renderers can draw it as a nested cluster.
+func WithSubGraph(sub *Graph) NodeOption {
+	return func(c *NodeConfig) {
+		c.SubGraph = sub
+	}
+}
+
+// ExponentialBackoff doubles the wait after every retry, starting at base and capped at maxDelay.
+func ExponentialBackoff(base, maxDelay time.Duration) func(retry int) time.Duration {
+	return func(retry int) time.Duration {
+		d := base
+		for i := 1; i < retry && d < maxDelay; i++ {
+			d *= 2
+		}
+		return min(d, maxDelay)
+	}
+}
+
+// runNode executes a node under its configured policy. When every attempt
+// fails and the node has a fallback, the returned result carries the state as
+// it was before the step.
+func (g *Graph) runNode(ctx context.Context, name string, node NodeFunc, state State) (NodeResult, error) {
+	cfg, ok := g.NodeConfigs[name]
+	if !ok {
+		return node(ctx, state)
+	}
+
+	// 1. Snapshot the state so retries and fallbacks start from a clean slate.
+	var snapshot State
+	if cfg.MaxAttempts > 1 || cfg.Fallback != "" {
+		var err error
+		snapshot, err = state.DeepCopy()
+		if err != nil {
+			return NodeResult{State: state}, fmt.Errorf("failed to snapshot state before node: %w", err)
+		}
+	}
+
+	retryable := cfg.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	backoff := cfg.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff(200*time.Millisecond, 5*time.Second)
+	}
+
+	// 2. Attempt the node, restoring the snapshot before every retry.
+	attempts := max(cfg.MaxAttempts, 1)
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		input := state
+		if attempt > 1 {
+			restored, err := snapshot.DeepCopy()
+			if err != nil {
+				return NodeResult{State: state}, fmt.Errorf("failed to restore state for retry: %w", err)
+			}
+			input = restored
+		}
+
+		result, err := callWithTimeout(ctx, cfg.Timeout, node, input)
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+
+		if attempt == attempts || ctx.Err() != nil || !retryable(err) {
+			break
+		}
+
+		// 3. Wait before the next attempt, but never past cancellation.
+		select {
+		case <-ctx.Done():
+			return NodeResult{State: state}, ctx.Err()
+		case <-time.After(backoff(attempt)):
+		}
+	}
+
+	if snapshot != nil {
+		return NodeResult{State: snapshot}, lastErr
+	}
+	return NodeResult{State: state}, lastErr
+}
+
+// callWithTimeout runs a single attempt under a derived context.
+func callWithTimeout(ctx context.Context, timeout time.Duration, node NodeFunc, state State) (NodeResult, error) {
+	if timeout <= 0 {
+		return node(ctx, state)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := node(attemptCtx, state)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return result, fmt.Errorf("node timed out after %s: %w", timeout, err)
+	}
+	return result, err
+}
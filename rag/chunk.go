@@ -0,0 +1,129 @@
+package rag
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ChunkConfig controls how sources are split.
+type ChunkConfig struct {
+	// Size is the maximum length of a chunk in characters. Defaults to 1000.
+	Size int
+	// Overlap is how many characters consecutive chunks share, so a passage
+	// cut at a boundary is still found whole in one of them. Defaults to 200,
+	// negative means none, and it is capped at half of Size.
+	Overlap int
+	// Headings starts a new chunk at every Markdown heading and records the
+	// heading in the chunk's metadata.
+	Headings bool
+}
+
+// Chunk is a piece of a source small enough to embed.
+type Chunk struct {
+	ID       string // Source ID and position, e.g. "guide.md#3"
+	SourceID string
+	Text     string
+	Metadata map[string]string
+}
+
+func (c ChunkConfig) withDefaults() ChunkConfig {
+	if c.Size <= 0 {
+		c.Size = 1000
+	}
+	if c.Overlap < 0 {
+		c.Overlap = 0
+	} else if c.Overlap == 0 {
+		c.Overlap = 200
+	}
+	c.Overlap = min(c.Overlap, c.Size/2)
+	return c
+}
+
+// Split cuts a source into chunks. Chunks inherit the source's metadata plus
+// "source", "chunk" and, with Headings, "heading".
+func Split(src Source, cfg ChunkConfig) []Chunk {
+	cfg = cfg.withDefaults()
+
+	type section struct{ heading, text string }
+	sections := []section{{text: src.Text}}
+	if cfg.Headings {
+		sections = sections[:0]
+		var current section
+		var body strings.Builder
+		flush := func() {
+			current.text = body.String()
+			sections = append(sections, current)
+			body.Reset()
+		}
+		for _, line := range strings.SplitAfter(src.Text, "\n") {
+			if level, title := headingLevel(strings.TrimRight(line, "\r\n")); level > 0 {
+				flush()
+				current = section{heading: title}
+			}
+			body.WriteString(line)
+		}
+		flush()
+	}
+
+	var chunks []Chunk
+	for _, sec := range sections {
+		for _, text := range window([]rune(sec.text), cfg.Size, cfg.Overlap) {
+			n := len(chunks)
+			metadata := map[string]string{}
+			for k, v := range src.Metadata {
+				metadata[k] = v
+			}
+			metadata["source"] = src.ID
+			metadata["chunk"] = strconv.Itoa(n)
+			if sec.heading != "" {
+				metadata["heading"] = sec.heading
+			}
+			chunks = append(chunks, Chunk{
+				ID:       fmt.Sprintf("%s#%d", src.ID, n),
+				SourceID: src.ID,
+				Text:     text,
+				Metadata: metadata,
+			})
+		}
+	}
+	return chunks
+}
+
+// breakPoints are where a chunk prefers to end, best first.
+var breakPoints = []string{"\n\n", "\n", ". ", " "}
+
+// window slides over text in steps of at most size characters, ending
+// chunks at paragraph, line, sentence or word boundaries when it can.
+func window(text []rune, size, overlap int) []string {
+	var pieces []string
+	start := 0
+	for start < len(text) {
+		end := min(start+size, len(text))
+		if end < len(text) {
+			// Look for a natural break in the second half of the window.
+			s := string(text[start+size/2 : end])
+			for _, sep := range breakPoints {
+				if i := strings.LastIndex(s, sep); i >= 0 {
+					end = start + size/2 + len([]rune(s[:i+len(sep)]))
+					break
+				}
+			}
+		}
+		if piece := strings.TrimSpace(string(text[start:end])); piece != "" {
+			pieces = append(pieces, piece)
+		}
+		if end == len(text) {
+			break
+		}
+
+		// Step back by the overlap, then forward to the start of a word.
+		next := max(end-overlap, start+1)
+		for next < end && next > 0 && !unicode.IsSpace(text[next-1]) {
+			next++
+		}
+		start = next
+	}
+	return pieces
+}
@@ -0,0 +1,88 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/amangsingh/agora/llm"
+	"github.com/amangsingh/agora/vectorstore"
+)
+
+// Ingester chunks sources, embeds the chunks and stores them.
+type Ingester struct {
+	Embedder llm.Embedder
+	Store    vectorstore.VectorStore
+	Chunking ChunkConfig
+}
+
+// NewIngester creates an Ingester with the default chunking.
+func NewIngester(embedder llm.Embedder, store vectorstore.VectorStore) *Ingester {
+	return &Ingester{Embedder: embedder, Store: store}
+}
+
+// Ingest stores every chunk of the sources and returns how many were
+// stored. Re-ingesting a source replaces its chunks, including removing
+// ones a shorter new version no longer has, or all of them when it is now
+// empty.
+func (in *Ingester) Ingest(ctx context.Context, sources ...Source) (int, error) {
+	total := 0
+	for _, src := range sources {
+		n, err := in.ingest(ctx, src)
+		if err != nil {
+			return total, fmt.Errorf("failed to ingest %s: %w", src.ID, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (in *Ingester) ingest(ctx context.Context, src Source) (int, error) {
+	// 1. Chunk and embed. An empty source has nothing to embed, but the
+	// lookup below still needs a query vector, so its ID stands in.
+	chunks := Split(src, in.Chunking)
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+	if len(chunks) == 0 {
+		texts = []string{src.ID}
+	}
+	vectors, err := in.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return 0, err
+	}
+	if len(vectors) != len(texts) {
+		return 0, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(vectors))
+	}
+
+	// 2. Find chunks left over from a previous version of the source.
+	previous, err := in.Store.Search(ctx, vectors[0], 0, vectorstore.Filter{"source": src.ID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up previous chunks: %w", err)
+	}
+	current := make(map[string]bool, len(chunks))
+	docs := make([]vectorstore.Document, len(chunks))
+	for i, c := range chunks {
+		current[c.ID] = true
+		docs[i] = vectorstore.Document{ID: c.ID, Content: c.Text, Metadata: c.Metadata, Vector: vectors[i]}
+	}
+	var stale []string
+	for _, r := range previous {
+		if !current[r.ID] {
+			stale = append(stale, r.ID)
+		}
+	}
+
+	// 3. Store the new chunks and drop the stale ones.
+	if len(docs) > 0 {
+		if err := in.Store.Upsert(ctx, docs...); err != nil {
+			return 0, err
+		}
+	}
+	if len(stale) > 0 {
+		if err := in.Store.Delete(ctx, stale...); err != nil {
+			return 0, err
+		}
+	}
+	return len(docs), nil
+}
@@ -0,0 +1,174 @@
+// Package rag loads documents, splits them into chunks and ingests them into
+// a vector store for retrieval-augmented generation. nodes.RetrieverNode and
+// nodes.RAGAgentNode answer questions from what was ingested.
+package rag
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Source is a loaded document, ready to be chunked.
+type Source struct {
+	ID       string
+	Text     string
+	Metadata map[string]string
+}
+
+// Loader turns raw content into a Source.
+type Loader func(id string, r io.Reader) (Source, error)
+
+// loaders maps file extensions to the loader that understands them.
+var loaders = map[string]Loader{
+	".txt":      LoadText,
+	".md":       LoadMarkdown,
+	".markdown": LoadMarkdown,
+	".html":     LoadHTML,
+	".htm":      LoadHTML,
+}
+
+// LoadText loads plain text as is.
+func LoadText(id string, r io.Reader) (Source, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to read %s: %w", id, err)
+	}
+	return Source{ID: id, Text: string(data), Metadata: map[string]string{"format": "text"}}, nil
+}
+
+// LoadMarkdown loads Markdown, keeping its headings for ChunkConfig.Headings
+// and using the first one as the title.
+func LoadMarkdown(id string, r io.Reader) (Source, error) {
+	src, err := LoadText(id, r)
+	if err != nil {
+		return Source{}, err
+	}
+	src.Metadata["format"] = "markdown"
+	for _, line := range strings.Split(src.Text, "\n") {
+		if level, title := headingLevel(line); level > 0 {
+			src.Metadata["title"] = title
+			break
+		}
+	}
+	return src, nil
+}
+
+var (
+	htmlDropped  = regexp.MustCompile(`(?is)<!--.*?-->|<(script|style|noscript|head)\b.*?</(script|style|noscript|head)>`)
+	htmlTitle    = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlHeading  = regexp.MustCompile(`(?is)<h([1-6])[^>]*>(.*?)</h[1-6]>`)
+	htmlBlock    = regexp.MustCompile(`(?i)<(br|p|div|li|tr|section|article|blockquote|pre|table|ul|ol)\b[^>]*>|</(p|div|section|article|blockquote|pre|table|ul|ol)>`)
+	htmlTag      = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLines   = regexp.MustCompile(`\n{3,}`)
+	inlineSpaces = regexp.MustCompile(`[ \t\r\f\v\x{00a0}]+`)
+)
+
+// LoadHTML extracts the readable text of an HTML page. Scripts, styles and
+// comments are dropped and headings become Markdown headings, so
+// ChunkConfig.Headings works on web pages too.
+func LoadHTML(id string, r io.Reader) (Source, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to read %s: %w", id, err)
+	}
+	page := string(data)
+	metadata := map[string]string{"format": "html"}
+	if m := htmlTitle.FindStringSubmatch(page); m != nil {
+		metadata["title"] = strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(m[1], "")))
+	}
+
+	// 1. Drop invisible content, then turn structure into line breaks.
+	page = htmlDropped.ReplaceAllString(page, "")
+	page = htmlHeading.ReplaceAllStringFunc(page, func(h string) string {
+		m := htmlHeading.FindStringSubmatch(h)
+		return "\n\n" + strings.Repeat("#", int(m[1][0]-'0')) + " " + htmlTag.ReplaceAllString(m[2], "") + "\n\n"
+	})
+	page = htmlBlock.ReplaceAllString(page, "\n")
+	page = html.UnescapeString(htmlTag.ReplaceAllString(page, ""))
+
+	// 2. Normalize whitespace line by line.
+	lines := strings.Split(page, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(inlineSpaces.ReplaceAllString(line, " "))
+	}
+	text := blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return Source{ID: id, Text: strings.TrimSpace(text), Metadata: metadata}, nil
+}
+
+// LoadFile loads a file with the loader for its extension, falling back to
+// plain text. The path is the source ID.
+func LoadFile(path string) (Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	load, ok := loaders[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		load = LoadText
+	}
+	src, err := load(path, f)
+	if err != nil {
+		return Source{}, err
+	}
+	src.Metadata["path"] = path
+	return src, nil
+}
+
+// LoadPaths loads files and, recursively, the text, Markdown and HTML files
+// inside directories.
+func LoadPaths(paths ...string) ([]Source, error) {
+	var sources []Source
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", root, err)
+		}
+		if !info.IsDir() {
+			src, err := LoadFile(root)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, src)
+			continue
+		}
+
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			if _, ok := loaders[strings.ToLower(filepath.Ext(path))]; !ok {
+				return nil
+			}
+			src, err := LoadFile(path)
+			if err != nil {
+				return err
+			}
+			sources = append(sources, src)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sources, nil
+}
+
+// headingLevel returns the level and title of a Markdown ATX heading, or 0.
+func headingLevel(line string) (int, string) {
+	level := 0
+	for level < len(line) && level < 6 && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level >= len(line) || line[level] != ' ' {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(line[level:])
+}
@@ -0,0 +1,251 @@
+// Package redact finds personal data and secrets in text and replaces them,
+// either for good with Redact or with placeholders that a Vault can turn
+// back into the originals. llm.RedactingLLM uses it to keep such data away
+// from model providers, and storage.Repository.SetRedactor to keep it out of
+// the database.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Rule finds one kind of sensitive data.
+type Rule struct {
+	// Name labels replacements, e.g. "EMAIL" gives "[EMAIL]" and "[EMAIL_1]".
+	Name    string
+	Pattern *regexp.Regexp
+	// Group is the submatch to replace, so a rule can keep context such as
+	// the "password=" before a value. Zero replaces the whole match.
+	Group int
+	// Valid, when set, rejects matches that only look right, such as card
+	// numbers failing the Luhn check.
+	Valid func(match string) bool
+}
+
+// Email matches email addresses.
+func Email() Rule {
+	return Rule{Name: "EMAIL", Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)}
+}
+
+// Phone matches phone numbers of 7 to 15 digits, with an optional country
+// code and the usual separators. Unseparated numbers need at least 10 digits,
+// so order numbers and the like are left alone, as are dates, times and IP
+// addresses.
+func Phone() Rule {
+	return Rule{
+		Name: "PHONE",
+		// A trailing ":\d" is matched only so that times can be rejected.
+		Pattern: regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{1,4}\)[\s.-]?)?\b\d{2,4}(?:[\s.-]?\d{2,4}){1,3}\b(?::\d+)*`),
+		Valid: func(match string) bool {
+			if dateLike.MatchString(match) || dottedQuad.MatchString(match) || strings.Contains(match, ":") {
+				return false
+			}
+			digits := countDigits(match)
+			if digits < 7 || digits > 15 {
+				return false
+			}
+			return digits >= 10 || digits != len(match)
+		},
+	}
+}
+
+// CreditCard matches card numbers of 13 to 19 digits, optionally grouped
+// with spaces or dashes, that pass the Luhn check.
+func CreditCard() Rule {
+	return Rule{
+		Name:    "CREDIT_CARD",
+		Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		Valid:   Luhn,
+	}
+}
+
+// Secret matches API keys and credentials in well-known formats: OpenAI,
+// GitHub, AWS and Slack keys, JWTs, bearer tokens and private keys.
+func Secret() Rule {
+	return Rule{
+		Name: "SECRET",
+		Pattern: regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}` +
+			`|\bgh[pousr]_[A-Za-z0-9]{36,}` +
+			`|\bAKIA[0-9A-Z]{16}\b` +
+			`|\bxox[abprs]-[A-Za-z0-9-]{10,}` +
+			`|\beyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}` +
+			`|(?i:\bbearer\s+)[A-Za-z0-9._~+/-]{20,}=*` +
+			`|-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	}
+}
+
+// Assignment matches the values given to keys that name secrets, as in
+// "api_key: abc123" or `password="hunter22"`, keeping the key.
+func Assignment() Rule {
+	return Rule{
+		Name:    "SECRET",
+		Pattern: regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret|token|password|passwd|pwd)\b["']?\s*[:=]\s*["']?([^\s"'\[][^\s"',;]{5,})`),
+		Group:   1,
+	}
+}
+
+// Custom builds a rule from a regular expression.
+func Custom(name, expr string) (Rule, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid pattern for %s: %w", name, err)
+	}
+	return Rule{Name: name, Pattern: re}, nil
+}
+
+// DefaultRules are the rules New uses when given none. Secrets come first
+// and phone numbers last, so a card number or key is never half-taken for a
+// phone number.
+func DefaultRules() []Rule {
+	return []Rule{Secret(), Assignment(), CreditCard(), Email(), Phone()}
+}
+
+// Luhn reports whether the digits of s pass the Luhn checksum, ignoring
+// anything else.
+func Luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+// dateLike matches dates, with or without a time, and dottedQuad IPv4
+// addresses, which Phone would otherwise take for numbers.
+var (
+	dateLike   = regexp.MustCompile(`^(?:\d{4}[-./]\d{1,2}[-./]\d{1,2}|\d{1,2}[-./]\d{1,2}[-./]\d{2,4})(?:[\sT]\d{1,2}(?::\d{2}){0,2})?$`)
+	dottedQuad = regexp.MustCompile(`^\d{1,3}(?:\.\d{1,3}){3}$`)
+)
+
+func countDigits(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// Redactor applies rules to text, in order.
+type Redactor struct {
+	rules []Rule
+}
+
+// New creates a Redactor. Without rules it uses DefaultRules.
+func New(rules ...Rule) *Redactor {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+	return &Redactor{rules: rules}
+}
+
+// Redact replaces every match with its rule's name, e.g. "[EMAIL]". It
+// cannot be undone.
+func (r *Redactor) Redact(text string) string {
+	return r.replace(text, func(rule Rule, value string) string {
+		return "[" + rule.Name + "]"
+	})
+}
+
+// Mask replaces every match with a numbered placeholder, e.g. "[EMAIL_1]",
+// remembering the original in the vault. The same value always gets the
+// same placeholder from one vault.
+func (r *Redactor) Mask(text string, v *Vault) string {
+	return r.replace(text, v.placeholder)
+}
+
+func (r *Redactor) replace(text string, with func(rule Rule, value string) string) string {
+	for _, rule := range r.rules {
+		matches := rule.Pattern.FindAllStringSubmatchIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		out := make([]byte, 0, len(text))
+		last := 0
+		for _, m := range matches {
+			start, end := m[2*rule.Group], m[2*rule.Group+1]
+			if start < last {
+				continue
+			}
+			value := text[start:end]
+			if rule.Valid != nil && !rule.Valid(value) {
+				continue
+			}
+			out = append(out, text[last:start]...)
+			out = append(out, with(rule, value)...)
+			last = end
+		}
+		text = string(append(out, text[last:]...))
+	}
+	return text
+}
+
+// Vault remembers what Mask replaced, so replies mentioning a placeholder can
+// be restored. It is safe for concurrent use.
+type Vault struct {
+	mu       sync.Mutex
+	values   map[string]string // placeholder -> original
+	masks    map[string]string // original -> placeholder
+	counters map[string]int
+}
+
+// NewVault creates an empty vault.
+func NewVault() *Vault {
+	return &Vault{values: map[string]string{}, masks: map[string]string{}, counters: map[string]int{}}
+}
+
+func (v *Vault) placeholder(rule Rule, value string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key := rule.Name + "\x00" + value
+	if p, ok := v.masks[key]; ok {
+		return p
+	}
+	v.counters[rule.Name]++
+	p := "[" + rule.Name + "_" + strconv.Itoa(v.counters[rule.Name]) + "]"
+	v.masks[key] = p
+	v.values[p] = value
+	return p
+}
+
+var placeholderPattern = regexp.MustCompile(`\[[A-Za-z][A-Za-z0-9_]*_\d+\]`)
+
+// Restore puts the originals back in place of the vault's placeholders.
+// Placeholders the vault never issued are left as they are.
+func (v *Vault) Restore(text string) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.values) == 0 {
+		return text
+	}
+	return placeholderPattern.ReplaceAllStringFunc(text, func(p string) string {
+		if value, ok := v.values[p]; ok {
+			return value
+		}
+		return p
+	})
+}
+
+// Len returns how many values the vault holds.
+func (v *Vault) Len() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.values)
+}
@@ -0,0 +1,208 @@
+// in agora/render.go
+
+package agora
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Edge kinds in a rendered topology.
+const (
+	edgeStatic      = "edge"
+	edgeConditional = "conditional"
+	edgeRoute       = "route"
+	edgeFallback    = "error"
+)
+
+// topoNode is a node in the renderable topology. Kind is "node", "start" or "end".
+type topoNode struct {
+	id, label, kind string
+}
+
+type topoEdge struct {
+	from, to, kind string
+}
+
+// topoCluster is a graph (or sub-graph) with its nodes and nested clusters.
+type topoCluster struct {
+	id, label string
+	nodes     []topoNode
+	clusters  []*topoCluster
+}
+
+// topology is the renderer-neutral view of a graph shared by Mermaid and DOT.
+type topology struct {
+	root  *topoCluster
+	edges []topoEdge
+}
+
+// Mermaid renders the graph topology as a Mermaid flowchart: static edges as
+// solid arrows, declared conditional destinations, routes and fallbacks as
+// dashed arrows, and sub-graphs declared with WithSubGraph as nested clusters.
+func (g *Graph) Mermaid() string {
+	topo := g.topology()
+	id := mermaidID
+
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+
+	var writeCluster func(c *topoCluster, indent string)
+	writeCluster = func(c *topoCluster, indent string) {
+		for _, n := range c.nodes {
+			switch n.kind {
+			case "start", "end":
+				fmt.Fprintf(&b, "%s%s([%s])\n", indent, id(n.id), n.label)
+			default:
+				fmt.Fprintf(&b, "%s%s[%s]\n", indent, id(n.id), mermaidLabel(n.label))
+			}
+		}
+		for _, child := range c.clusters {
+			fmt.Fprintf(&b, "%ssubgraph %s [%s]\n", indent, id(child.id), mermaidLabel(child.label))
+			writeCluster(child, indent+"    ")
+			fmt.Fprintf(&b, "%send\n", indent)
+		}
+	}
+	writeCluster(topo.root, "    ")
+
+	for _, e := range topo.edges {
+		switch e.kind {
+		case edgeStatic:
+			fmt.Fprintf(&b, "    %s --> %s\n", id(e.from), id(e.to))
+		case edgeConditional:
+			fmt.Fprintf(&b, "    %s -.-> %s\n", id(e.from), id(e.to))
+		default:
+			fmt.Fprintf(&b, "    %s -.->|%s| %s\n", id(e.from), e.kind, id(e.to))
+		}
+	}
+	return b.String()
+}
+
+// DOT renders the graph topology in Graphviz DOT format, using the same
+// conventions as Mermaid. Sub-graphs become nested "cluster_" subgraphs.
+func (g *Graph) DOT() string {
+	topo := g.topology()
+
+	var b strings.Builder
+	b.WriteString("digraph agora {\n    rankdir=TB;\n")
+
+	var writeCluster func(c *topoCluster, indent string)
+	writeCluster = func(c *topoCluster, indent string) {
+		for _, n := range c.nodes {
+			shape := "box"
+			if n.kind != "node" {
+				shape = "ellipse"
+			}
+			fmt.Fprintf(&b, "%s%q [label=%q, shape=%s];\n", indent, n.id, n.label, shape)
+		}
+		for _, child := range c.clusters {
+			fmt.Fprintf(&b, "%ssubgraph %q {\n%s    label=%q;\n", indent, "cluster_"+child.id, indent, child.label)
+			writeCluster(child, indent+"    ")
+			fmt.Fprintf(&b, "%s}\n", indent)
+		}
+	}
+	writeCluster(topo.root, "    ")
+
+	for _, e := range topo.edges {
+		switch e.kind {
+		case edgeStatic:
+			fmt.Fprintf(&b, "    %q -> %q;\n", e.from, e.to)
+		case edgeConditional:
+			fmt.Fprintf(&b, "    %q -> %q [style=dashed];\n", e.from, e.to)
+		default:
+			fmt.Fprintf(&b, "    %q -> %q [style=dashed, label=%q];\n", e.from, e.to, e.kind)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// mermaidID turns a node path into a Mermaid identifier. Letters and digits
+// are kept, "_" is doubled and any other byte becomes "_" and two hex digits,
+// so distinct names such as "a-b" and "a_b" never share an ID.
+func mermaidID(raw string) string {
+	var b strings.Builder
+	b.WriteString("n_")
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b.WriteByte(c)
+		case c == '_':
+			b.WriteString("__")
+		default:
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	return b.String()
+}
+
+// mermaidLabel quotes a label for Mermaid, which reads no backslash escapes:
+// quotes become the #quot; entity and everything else is written as-is.
+func mermaidLabel(label string) string {
+	return `"` + strings.ReplaceAll(label, `"`, "#quot;") + `"`
+}
+
+// topology flattens the graph and its introspectable sub-graphs into nodes,
+// clusters and edges.
+func (g *Graph) topology() topology {
+	topo := topology{}
+	topo.root = g.buildCluster(&topo, "", "", map[*Graph]bool{})
+	return topo
+}
+
+// buildCluster adds g's nodes under prefix. Edges into a sub-graph node enter
+// at the child's START, and edges out of it leave from the child's END.
+func (g *Graph) buildCluster(topo *topology, prefix, label string, ancestors map[*Graph]bool) *topoCluster {
+	ancestors[g] = true
+	defer delete(ancestors, g)
+
+	cluster := &topoCluster{id: prefix, label: label}
+	startID, endID := joinPath(prefix, "__start__"), joinPath(prefix, "__end__")
+	cluster.nodes = append(cluster.nodes, topoNode{id: startID, label: "START", kind: "start"})
+
+	// Resolve the anchors edges attach to for each node.
+	in := func(name string) string {
+		if name == END {
+			return endID
+		}
+		if sub := g.NodeConfigs[name].SubGraph; sub != nil && !ancestors[sub] {
+			return joinPath(joinPath(prefix, name), "__start__")
+		}
+		return joinPath(prefix, name)
+	}
+	out := func(name string) string {
+		if sub := g.NodeConfigs[name].SubGraph; sub != nil && !ancestors[sub] {
+			return joinPath(joinPath(prefix, name), "__end__")
+		}
+		return joinPath(prefix, name)
+	}
+
+	for _, name := range sortedKeys(g.Nodes) {
+		if sub := g.NodeConfigs[name].SubGraph; sub != nil && !ancestors[sub] {
+			cluster.clusters = append(cluster.clusters, sub.buildCluster(topo, joinPath(prefix, name), name, ancestors))
+			continue
+		}
+		cluster.nodes = append(cluster.nodes, topoNode{id: joinPath(prefix, name), label: name, kind: "node"})
+	}
+	cluster.nodes = append(cluster.nodes, topoNode{id: endID, label: "END", kind: "end"})
+
+	if g.Entry != "" {
+		topo.edges = append(topo.edges, topoEdge{from: startID, to: in(g.Entry), kind: edgeStatic})
+	}
+	for _, name := range sortedKeys(g.Nodes) {
+		if target, ok := g.Edges[name]; ok {
+			topo.edges = append(topo.edges, topoEdge{from: out(name), to: in(target), kind: edgeStatic})
+		}
+		for _, target := range g.ConditionalTargets[name] {
+			topo.edges = append(topo.edges, topoEdge{from: out(name), to: in(target), kind: edgeConditional})
+		}
+		cfg := g.NodeConfigs[name]
+		for _, target := range cfg.Routes {
+			topo.edges = append(topo.edges, topoEdge{from: out(name), to: in(target), kind: edgeRoute})
+		}
+		if cfg.Fallback != "" {
+			topo.edges = append(topo.edges, topoEdge{from: out(name), to: in(cfg.Fallback), kind: edgeFallback})
+		}
+	}
+	return cluster
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+// TestTrace_UsageReport verifies usage is aggregated per node, per model and
+// overall, priced from the table, and includes sub-graph calls.
+func TestTrace_UsageReport(t *testing.T) {
+	report := func(model string, prompt, completion int) agora.NodeFunc {
+		return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+			agora.ReportModelResponse(ctx, agora.ModelResponse{
+				Model: model,
+				Usage: agora.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion},
+			})
+			return agora.NodeResult{State: s}, nil
+		}
+	}
+
+	sub := agora.NewGraph()
+	sub.SetEntry("inner")
+	sub.AddNode("inner", report("local-llama", 100, 100))
+
+	g := agora.NewGraph()
+	g.SetEntry("plan")
+	g.AddNode("plan", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		report("gpt-4o-2024-08-06", 1000, 500)(ctx, s)
+		return report("gpt-4o-mini", 2000, 0)(ctx, s)
+	})
+	g.AddNode("delegate", nodes.SubGraphNode(sub))
+	g.AddEdge("plan", "delegate")
+
+	_, trace, err := g.ExecuteWithTrace(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	prices := agora.PriceTable{
+		"gpt-4o":      {InputPerMillion: 2.5, OutputPerMillion: 10},
+		"gpt-4o-mini": {InputPerMillion: 0.15, OutputPerMillion: 0.6},
+	}
+	got := trace.UsageReport(prices)
+
+	// gpt-4o prefix: 1000*2.5/1e6 + 500*10/1e6; gpt-4o-mini exact: 2000*0.15/1e6.
+	wantCost := 0.0025 + 0.005 + 0.0003
+	if math.Abs(got.Total.Cost-wantCost) > 1e-12 {
+		t.Errorf("expected total cost %v, got %v", wantCost, got.Total.Cost)
+	}
+	if got.Total.Calls != 3 || got.Total.TotalTokens != 3700 {
+		t.Errorf("unexpected totals: %+v", got.Total)
+	}
+	if got.ByNode["plan"].Calls != 2 || got.ByNode["delegate/inner"].TotalTokens != 200 {
+		t.Errorf("unexpected per-node usage: %+v", got.ByNode)
+	}
+	if got.ByModel["gpt-4o-mini"].PromptTokens != 2000 {
+		t.Errorf("unexpected per-model usage: %+v", got.ByModel)
+	}
+	if len(got.Entries) != 3 || got.Entries[0].Node != "delegate/inner" {
+		t.Errorf("expected 3 sorted entries, got %+v", got.Entries)
+	}
+	if !reflect.DeepEqual(got.Unpriced, []string{"local-llama"}) {
+		t.Errorf("expected local-llama to be unpriced, got %v", got.Unpriced)
+	}
+
+	// Without prices, tokens are still reported and nothing is flagged.
+	if free := trace.UsageReport(nil); free.Total.Cost != 0 || free.Total.TotalTokens != 3700 || len(free.Unpriced) != 0 {
+		t.Errorf("unexpected unpriced report: %+v", free)
+	}
+}
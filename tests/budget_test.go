@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+// spendingLLM answers every call with the given token usage.
+func spendingLLM(tokens int) *MockLLM {
+	return &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			return agora.ModelResponse{
+				Model:   "gpt-4o",
+				Choices: []agora.Choice{{Message: agora.ChatMessage{Role: "assistant", Content: "more"}}},
+				Usage:   agora.Usage{PromptTokens: tokens, TotalTokens: tokens},
+			}, nil
+		},
+	}
+}
+
+// loopingGraph runs an agent node forever.
+func loopingGraph(l *MockLLM) *agora.Graph {
+	g := agora.NewGraph()
+	g.MaxSteps = 100
+	g.SetEntry("agent")
+	g.AddNode("agent", nodes.SimpleAgentNode(l, "keep going"))
+	g.AddEdge("agent", "agent")
+	return g
+}
+
+// TestBudget_Tokens verifies that the step overshooting the token budget
+// completes, but the run stops with a typed error naming the limit.
+func TestBudget_Tokens(t *testing.T) {
+	g := loopingGraph(spendingLLM(100))
+	g.Budget = agora.Budget{MaxTokens: 250}
+
+	_, trace, err := g.ExecuteWithTrace(context.Background(), newTestState())
+	if !errors.Is(err, agora.ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+	var budgetErr *agora.BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != agora.LimitTokens || budgetErr.Used != 300 {
+		t.Errorf("expected tokens limit with 300 used, got %+v", budgetErr)
+	}
+	if len(trace.Steps) != 3 {
+		t.Errorf("expected 3 steps, got %d", len(trace.Steps))
+	}
+}
+
+// TestBudget_Cost verifies cost is priced from the budget's table and that a
+// used-up budget refuses the next LLM call without reaching the model.
+func TestBudget_Cost(t *testing.T) {
+	l := spendingLLM(1000)
+	var calls atomic.Int32
+	invoke := l.InvokeFunc
+	l.InvokeFunc = func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+		calls.Add(1)
+		return invoke(ctx, request)
+	}
+
+	g := agora.NewGraph()
+	g.SetEntry("agent")
+	g.AddNode("agent", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		// An agent that calls the model twice in one step.
+		agent := nodes.SimpleAgentNode(l, "be brief")
+		if _, err := agent(ctx, s); err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		return agent(ctx, s)
+	})
+	g.Budget = agora.Budget{MaxCost: 0.001, Prices: agora.PriceTable{"gpt-4o": {InputPerMillion: 1}}}
+
+	_, err := g.Execute(context.Background(), newTestState())
+	var budgetErr *agora.BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != agora.LimitCost {
+		t.Fatalf("expected cost limit, got %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected the second call to be refused, got %d calls", calls.Load())
+	}
+}
+
+// TestBudget_SharedWithSubGraphs verifies nested graphs and parallel branches
+// charge the parent's budget, and that a budget error skips fallbacks.
+func TestBudget_SharedWithSubGraphs(t *testing.T) {
+	l := spendingLLM(1)
+	sub := agora.NewGraph()
+	sub.SetEntry("agent")
+	sub.AddNode("agent", nodes.SimpleAgentNode(l, "help"))
+
+	branch := nodes.SubGraphNode(sub)
+	g := agora.NewGraph()
+	g.SetEntry("fan_out")
+	g.AddNode("fan_out", nodes.ParallelNode([]agora.NodeFunc{branch, branch, branch}, func(original agora.State, _ []agora.State) agora.State {
+		return original
+	}))
+	g.AddNode("again", branch, agora.WithFallback("recover"))
+	g.AddNode("recover", passThrough)
+	g.AddEdge("fan_out", "again")
+	g.Budget = agora.Budget{MaxLLMCalls: 3}
+
+	_, trace, err := g.ExecuteWithTrace(context.Background(), newTestState())
+	var budgetErr *agora.BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != agora.LimitLLMCalls || budgetErr.Used != 3 {
+		t.Fatalf("expected llm_calls limit after 3 calls, got %v", err)
+	}
+	if path := trace.Path(); len(path) != 2 || path[1] != "again" {
+		t.Errorf("expected the run to stop in again without falling back, got %v", path)
+	}
+}
+
+type countingTool struct{ calls int }
+
+func (c *countingTool) Definition() agora.ToolDefinition {
+	return agora.ToolDefinition{Type: "function", Function: agora.Function{Name: "count"}}
+}
+
+func (c *countingTool) Execute(ctx context.Context, args map[string]interface{}) (any, error) {
+	c.calls++
+	return c.calls, nil
+}
+
+// TestBudget_ToolCalls verifies the tool executor stops at MaxToolCalls.
+func TestBudget_ToolCalls(t *testing.T) {
+	tool := &countingTool{}
+	registry := agora.NewToolRegistry()
+	registry.Register(tool)
+
+	g := agora.NewGraph()
+	g.SetEntry("tools")
+	g.AddNode("tools", nodes.ToolExecutorNode(registry))
+	g.Budget = agora.Budget{MaxToolCalls: 2}
+
+	state := newTestState()
+	call := agora.ToolCall{ID: "1", Type: "function"}
+	call.Function.Name = "count"
+	state.Set("tool_calls", []agora.ToolCall{call, call, call})
+
+	_, err := g.Execute(context.Background(), state)
+	var budgetErr *agora.BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != agora.LimitToolCalls {
+		t.Fatalf("expected tool_calls limit, got %v", err)
+	}
+	if tool.calls != 2 {
+		t.Errorf("expected 2 tool executions, got %d", tool.calls)
+	}
+}
+
+// TestBudget_ToolCallsConcurrent verifies concurrent tool calls in a
+// sub-graph with its own budget never overshoot the parent's MaxToolCalls.
+func TestBudget_ToolCallsConcurrent(t *testing.T) {
+	var allowed atomic.Int32
+	sub := agora.NewGraph()
+	sub.SetEntry("burst")
+	sub.AddNode("burst", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if agora.ReportToolCall(ctx) == nil {
+					allowed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		return agora.NodeResult{State: s, IsDone: true}, nil
+	})
+	sub.Budget = agora.Budget{MaxToolCalls: 100}
+
+	g := agora.NewGraph()
+	g.SetEntry("sub")
+	g.AddNode("sub", nodes.SubGraphNode(sub))
+	g.Budget = agora.Budget{MaxToolCalls: 5}
+
+	if _, err := g.Execute(context.Background(), newTestState()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := allowed.Load(); n != 5 {
+		t.Errorf("expected exactly 5 tool calls to be allowed, got %d", n)
+	}
+}
+
+// TestBudget_WallClock verifies a slow node is cancelled and the run reports
+// the wall-clock limit rather than a bare deadline error.
+func TestBudget_WallClock(t *testing.T) {
+	g := agora.NewGraph()
+	g.SetEntry("slow")
+	g.AddNode("slow", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		<-ctx.Done()
+		return agora.NodeResult{State: s}, ctx.Err()
+	})
+	g.Budget = agora.Budget{MaxWallClock: 20 * time.Millisecond}
+
+	_, err := g.Execute(context.Background(), newTestState())
+	var budgetErr *agora.BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Limit != agora.LimitWallClock {
+		t.Fatalf("expected wall_clock limit, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+// countingLLM answers with the call number so cached answers are recognizable.
+func countingLLM(calls *int) *MockLLM {
+	return &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			*calls++
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: agora.ChatMessage{Content: string(rune('0' + *calls))}}}}, nil
+		},
+	}
+}
+
+func userRequest(content string) agora.ModelRequest {
+	return agora.ModelRequest{Messages: []agora.ChatMessage{{Role: "user", Content: content}}}
+}
+
+// TestCachingLLM_HitsAndBypass verifies hits, misses and the bypass rules.
+func TestCachingLLM_HitsAndBypass(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	cached := llm.NewCachingLLM(countingLLM(&calls), llm.CacheConfig{})
+
+	for i := 0; i < 3; i++ {
+		resp, err := cached.Invoke(ctx, userRequest("hi"))
+		if err != nil || resp.Choices[0].Message.Content != "1" {
+			t.Fatalf("expected the first answer to be served, got %+v, %v", resp, err)
+		}
+	}
+
+	// Sampling without a seed and explicit bypasses skip the cache.
+	temperature := 0.7
+	sampled := userRequest("hi")
+	sampled.Temperature = &temperature
+	cached.Invoke(ctx, sampled)
+	cached.Invoke(llm.WithoutCache(ctx), userRequest("hi"))
+
+	// A seed makes sampling reproducible, so it is cached again.
+	seed := 42
+	sampled.Seed = &seed
+	cached.Invoke(ctx, sampled)
+	cached.Invoke(ctx, sampled)
+
+	stats := cached.Stats()
+	if calls != 4 || stats.Hits != 3 || stats.Misses != 2 || stats.Bypassed != 2 {
+		t.Errorf("unexpected calls=%d stats=%+v", calls, stats)
+	}
+}
+
+// TestCachingLLM_TTL verifies that expired entries are refreshed.
+func TestCachingLLM_TTL(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	cached := llm.NewCachingLLM(countingLLM(&calls), llm.CacheConfig{TTL: 20 * time.Millisecond})
+
+	cached.Invoke(ctx, userRequest("hi"))
+	cached.Invoke(ctx, userRequest("hi"))
+	time.Sleep(30 * time.Millisecond)
+	cached.Invoke(ctx, userRequest("hi"))
+
+	if calls != 2 {
+		t.Errorf("expected the expired entry to be refreshed, got %d calls", calls)
+	}
+}
+
+// TestMemoryCache_LRU verifies least-recently-used eviction.
+func TestMemoryCache_LRU(t *testing.T) {
+	ctx := context.Background()
+	cache := llm.NewMemoryCache(2)
+	cache.Set(ctx, "a", llm.CacheEntry{})
+	cache.Set(ctx, "b", llm.CacheEntry{})
+	cache.Get(ctx, "a") // a is now more recent than b
+	cache.Set(ctx, "c", llm.CacheEntry{})
+
+	if _, ok, _ := cache.Get(ctx, "b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok, _ := cache.Get(ctx, key); !ok {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+}
+
+// TestDiskCache_RoundTrip verifies entries survive a new store on the same directory.
+func TestDiskCache_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	first, err := llm.NewDiskCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := llm.CacheEntry{Response: agora.ModelResponse{Model: "m"}}
+	if err := first.Set(ctx, "ns:key", entry); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	second, _ := llm.NewDiskCache(dir)
+	got, ok, err := second.Get(ctx, "ns:key")
+	if err != nil || !ok || got.Response.Model != "m" {
+		t.Errorf("expected cached entry, got %+v, %v, %v", got, ok, err)
+	}
+}
+
+// TestCachingLLM_Stream verifies that a streamed answer is replayed chunk by chunk.
+func TestCachingLLM_Stream(t *testing.T) {
+	server := sseServer(t,
+		`{"id":"c1","choices":[{"index":0,"delta":{"role":"assistant","content":"a"}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"content":"b"}}]}`,
+	)
+	cached := llm.NewCachingLLM(llm.NewOpenAICompatibleLLM(server.URL, "m", "token"), llm.CacheConfig{})
+
+	collect := func() (string, int) {
+		text, chunks := "", 0
+		resp, err := cached.Stream(context.Background(), userRequest("hi"), func(chunk agora.ModelResponse) error {
+			text += chunk.Choices[0].Delta.Content
+			chunks++
+			return nil
+		})
+		if err != nil || resp.Choices[0].Message.Content != "ab" {
+			t.Fatalf("unexpected stream result %+v, %v", resp, err)
+		}
+		return text, chunks
+	}
+
+	collect()
+	server.Close() // The second stream must not need the server.
+	text, chunks := collect()
+	if text != "ab" || chunks != 2 {
+		t.Errorf("expected the cached chunks to be replayed, got %q in %d chunks", text, chunks)
+	}
+	if stats := cached.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
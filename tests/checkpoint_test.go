@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+// counterGraph increments "count" in a, records the input in b, and stops.
+func counterGraph(cp agora.Checkpointer) *agora.Graph {
+	g := agora.NewGraph()
+	g.Checkpointer = cp
+	g.SetEntry("a")
+	g.AddNode("a", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		count, _ := s.Get("count").(float64)
+		s.Set("count", count+1)
+		return agora.NodeResult{State: s}, nil
+	})
+	g.AddNode("b", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		s.Set("seen_input", s.(*agora.ConversationState).Input)
+		return agora.NodeResult{State: s}, nil
+	})
+	g.AddEdge("a", "b")
+	g.AddEdge("b", agora.END)
+	return g
+}
+
+// TestCheckpoints_PerStep verifies that a tagged run saves one checkpoint per step.
+func TestCheckpoints_PerStep(t *testing.T) {
+	cp := agora.NewMemoryCheckpointer()
+	g := counterGraph(cp)
+
+	ctx := agora.WithRunID(context.Background(), "run-1")
+	if _, err := g.Execute(ctx, newTestState()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkpoints, err := g.Checkpoints(ctx, "run-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Initial state, after a, after b.
+	if len(checkpoints) != 3 {
+		t.Fatalf("expected 3 checkpoints, got %d", len(checkpoints))
+	}
+	expected := []struct{ node, next string }{{"", "a"}, {"a", "b"}, {"b", agora.END}}
+	for i, want := range expected {
+		if checkpoints[i].Step != i || checkpoints[i].Node != want.node || checkpoints[i].Next != want.next {
+			t.Errorf("checkpoint %d: expected %+v, got %+v", i, want, checkpoints[i])
+		}
+	}
+
+	// Untagged runs are not checkpointed.
+	if _, err := g.Execute(context.Background(), newTestState()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cp.LoadCheckpoint(ctx, "", 0); !errors.Is(err, agora.ErrCheckpointNotFound) {
+		t.Errorf("expected no checkpoint for an untagged run, got %v", err)
+	}
+}
+
+// TestFork_EditsStateWithoutTouchingOriginal verifies forking from a past step.
+func TestFork_EditsStateWithoutTouchingOriginal(t *testing.T) {
+	cp := agora.NewMemoryCheckpointer()
+	g := counterGraph(cp)
+	ctx := context.Background()
+
+	if _, err := g.Execute(agora.WithRunID(ctx, "run-1"), newTestState()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fork after step 1 (a has run) with a different input.
+	final, err := g.Fork(ctx, agora.ForkRequest{
+		RunID:    "run-1",
+		Step:     1,
+		NewRunID: "run-2",
+		State:    &agora.ConversationState{},
+		Edit: func(s agora.State) error {
+			s.(*agora.ConversationState).Input = "edited"
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("fork failed: %v", err)
+	}
+	if final.Get("seen_input") != "edited" || final.Get("count") != float64(1) {
+		t.Errorf("expected b to see the edited input without re-running a, got %v", final.(*agora.ConversationState).Values)
+	}
+
+	// The fork records its lineage and continues the step numbering.
+	forked, _ := g.Checkpoints(ctx, "run-2")
+	if len(forked) != 2 || forked[0].ParentRunID != "run-1" || forked[0].ParentStep != 1 || forked[1].Step != 2 {
+		t.Errorf("unexpected fork checkpoints %+v", forked)
+	}
+
+	// The original run is untouched.
+	original, err := g.StateAt(ctx, "run-1", 2, &agora.ConversationState{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if original.Get("seen_input") != "test input" {
+		t.Errorf("original run was modified: %v", original.(*agora.ConversationState).Values)
+	}
+}
+
+// TestFork_InsideToolLoop verifies a fork between the agent and the tool
+// executor runs the tool calls restored from the checkpoint.
+func TestFork_InsideToolLoop(t *testing.T) {
+	tool := &countingTool{}
+	registry := agora.NewToolRegistry()
+	registry.Register(tool)
+	model := &MockLLM{InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+		for _, msg := range request.Messages {
+			if msg.Role == "tool" {
+				reply := agora.ChatMessage{Role: "assistant", Content: "count is " + msg.Content}
+				return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+			}
+		}
+		call := agora.ToolCall{ID: "call-1", Type: "function"}
+		call.Function.Name = "count"
+		reply := agora.ChatMessage{Role: "assistant", ToolCalls: []agora.ToolCall{call}}
+		return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+	}}
+
+	g := agora.NewGraph()
+	g.Checkpointer = agora.NewMemoryCheckpointer()
+	g.SetEntry("agent")
+	g.AddNode("agent", nodes.ToolAgentNode(model, "Count.", registry))
+	g.AddNode("tools", nodes.ToolExecutorNode(registry))
+	g.AddEdge("tools", "agent")
+	g.SetConditionalEdge("agent", func(s agora.State) string {
+		if calls, ok := s.Get("tool_calls").([]agora.ToolCall); ok && len(calls) > 0 {
+			return "tools"
+		}
+		return agora.END
+	}, "tools", agora.END)
+
+	ctx := context.Background()
+	if _, err := g.Execute(agora.WithRunID(ctx, "run-1"), newTestState()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Step 1 is the agent's tool call, with the executor next.
+	final, err := g.Fork(ctx, agora.ForkRequest{RunID: "run-1", Step: 1, NewRunID: "run-2", State: &agora.ConversationState{}})
+	if err != nil {
+		t.Fatalf("fork failed: %v", err)
+	}
+	if got := final.Get("output"); got != "count is 2" {
+		t.Errorf("expected the forked run to execute the tool again, got %v", got)
+	}
+}
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+// answeringLLM answers with content, or fails with err when it is set.
+func answeringLLM(content string, err error, calls *int) *MockLLM {
+	return &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			if calls != nil {
+				*calls++
+			}
+			if err != nil {
+				return agora.ModelResponse{}, err
+			}
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: agora.ChatMessage{Content: content}}}}, nil
+		},
+	}
+}
+
+var unavailable = &agora.StatusError{StatusCode: http.StatusServiceUnavailable, Body: "down"}
+
+// TestFallbackLLM verifies ordered fallback on retryable errors only.
+func TestFallbackLLM(t *testing.T) {
+	ctx := context.Background()
+	fallback := llm.NewFallbackLLM(
+		llm.Backend{Name: "primary", LLM: answeringLLM("", unavailable, nil)},
+		llm.Backend{Name: "secondary", LLM: answeringLLM("from secondary", nil, nil)},
+	)
+
+	resp, err := fallback.Invoke(ctx, agora.ModelRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Backend != "secondary" || resp.Choices[0].Message.Content != "from secondary" {
+		t.Errorf("expected the secondary to serve, got %+v", resp)
+	}
+
+	// A permanent error is returned without trying the next backend.
+	secondaryCalls := 0
+	badRequest := &agora.StatusError{StatusCode: http.StatusBadRequest, Body: "bad"}
+	fallback = llm.NewFallbackLLM(
+		llm.Backend{Name: "primary", LLM: answeringLLM("", badRequest, nil)},
+		llm.Backend{Name: "secondary", LLM: answeringLLM("unused", nil, &secondaryCalls)},
+	)
+	if _, err := fallback.Invoke(ctx, agora.ModelRequest{}); !errors.Is(err, badRequest) {
+		t.Errorf("expected the 400 to be returned, got %v", err)
+	}
+	if secondaryCalls != 0 {
+		t.Errorf("expected no fallback on a permanent error, got %d calls", secondaryCalls)
+	}
+}
+
+// TestBalancedLLM_WeightedRoundRobin verifies traffic follows the weights.
+func TestBalancedLLM_WeightedRoundRobin(t *testing.T) {
+	balanced := llm.NewBalancedLLM(llm.RoundRobin,
+		llm.Backend{Name: "big", LLM: answeringLLM("big", nil, nil), Weight: 3},
+		llm.Backend{Name: "small", LLM: answeringLLM("small", nil, nil), Weight: 1},
+	)
+
+	counts := map[string]int{}
+	for i := 0; i < 8; i++ {
+		resp, err := balanced.Invoke(context.Background(), agora.ModelRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[resp.Backend]++
+	}
+	if counts["big"] != 6 || counts["small"] != 2 {
+		t.Errorf("expected a 3:1 split, got %v", counts)
+	}
+}
+
+// TestBalancedLLM_LeastLatency verifies the fastest backend wins once measured.
+func TestBalancedLLM_LeastLatency(t *testing.T) {
+	slow := &MockLLM{InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+		time.Sleep(20 * time.Millisecond)
+		return agora.ModelResponse{}, nil
+	}}
+	balanced := llm.NewBalancedLLM(llm.LeastLatency,
+		llm.Backend{Name: "slow", LLM: slow},
+		llm.Backend{Name: "fast", LLM: answeringLLM("fast", nil, nil)},
+	)
+
+	var last string
+	for i := 0; i < 4; i++ {
+		resp, err := balanced.Invoke(context.Background(), agora.ModelRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = resp.Backend
+	}
+	if last != "fast" {
+		t.Errorf("expected the fast backend to be preferred, got %s", last)
+	}
+
+	// A backend that always fails is probed once, then left out.
+	dead := &MockLLM{InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+		return agora.ModelResponse{}, unavailable
+	}}
+	balanced = llm.NewBalancedLLM(llm.LeastLatency,
+		llm.Backend{Name: "dead", LLM: llm.NewCircuitBreakerLLM(dead, llm.CircuitBreakerConfig{FailureThreshold: 1})},
+		llm.Backend{Name: "healthy", LLM: answeringLLM("healthy", nil, nil)},
+	)
+	failures := 0
+	for i := 0; i < 20; i++ {
+		if _, err := balanced.Invoke(context.Background(), agora.ModelRequest{}); err != nil {
+			failures++
+		}
+	}
+	if failures != 1 {
+		t.Errorf("expected only the probe of the dead backend to fail, got %d failures", failures)
+	}
+}
+
+// TestCircuitBreakerLLM verifies ejection, cooldown and recovery.
+func TestCircuitBreakerLLM(t *testing.T) {
+	var failing bool
+	calls := 0
+	backend := &MockLLM{InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+		calls++
+		if failing {
+			return agora.ModelResponse{}, unavailable
+		}
+		return agora.ModelResponse{}, nil
+	}}
+	breaker := llm.NewCircuitBreakerLLM(backend, llm.CircuitBreakerConfig{FailureThreshold: 2, Cooldown: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	failing = true
+	breaker.Invoke(ctx, agora.ModelRequest{})
+	breaker.Invoke(ctx, agora.ModelRequest{})
+	if breaker.State() != llm.CircuitOpen {
+		t.Fatalf("expected the circuit to open, got %s", breaker.State())
+	}
+	if _, err := breaker.Invoke(ctx, agora.ModelRequest{}); !errors.Is(err, llm.ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected the open circuit to fail fast, got %d calls", calls)
+	}
+
+	// After the cooldown a successful trial closes the circuit.
+	time.Sleep(30 * time.Millisecond)
+	failing = false
+	if _, err := breaker.Invoke(ctx, agora.ModelRequest{}); err != nil {
+		t.Fatalf("expected the trial request to pass, got %v", err)
+	}
+	if breaker.State() != llm.CircuitClosed {
+		t.Errorf("expected the circuit to close, got %s", breaker.State())
+	}
+}
+
+// TestFallbackLLM_SkipsOpenCircuit verifies the composites work together.
+func TestFallbackLLM_SkipsOpenCircuit(t *testing.T) {
+	breaker := llm.NewCircuitBreakerLLM(answeringLLM("", unavailable, nil), llm.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Minute})
+	breaker.Invoke(context.Background(), agora.ModelRequest{}) // opens the circuit
+
+	fallback := llm.NewFallbackLLM(
+		llm.Backend{Name: "local", LLM: breaker},
+		llm.Backend{Name: "remote", LLM: answeringLLM("ok", nil, nil)},
+	)
+	resp, err := fallback.Invoke(context.Background(), agora.ModelRequest{})
+	if err != nil || resp.Backend != "remote" {
+		t.Errorf("expected the remote backend to serve, got %+v, %v", resp, err)
+	}
+}
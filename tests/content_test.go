@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+	"github.com/amangsingh/agora/redact"
+)
+
+// TestChatMessage_JSON verifies plain messages encode exactly as before, and
+// that content in either form decodes.
+func TestChatMessage_JSON(t *testing.T) {
+	// 1. Without parts, content stays a string.
+	data, err := json.Marshal(agora.ChatMessage{Role: "user", Content: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != `{"content":"hi","reasoning_content":"","role":"user"}` {
+		t.Errorf("unexpected encoding %s", data)
+	}
+
+	// 2. With parts, content is the list of parts, inline data as data: URLs.
+	png := []byte("\x89PNG fake")
+	msg := agora.NewMultimodalMessage("user",
+		agora.TextPart("What is in this screenshot?"),
+		agora.ImageDataPart("image/png", png),
+		agora.ImageURLPart("https://example.com/cat.jpg"),
+		agora.FileDataPart("report.pdf", "application/pdf", []byte("%PDF-1.4")),
+		agora.FileIDPart("file-123"),
+	)
+	if msg.Content != "What is in this screenshot?" {
+		t.Errorf("expected Content to hold the text, got %q", msg.Content)
+	}
+	data, err = json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		`"content":[{"type":"text","text":"What is in this screenshot?"}`,
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,`,
+		`{"type":"image_url","image_url":{"url":"https://example.com/cat.jpg"}}`,
+		`{"type":"file","file":{"filename":"report.pdf","file_data":"data:application/pdf;base64,`,
+		`{"type":"file","file":{"file_id":"file-123"}}`,
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected %s in %s", want, data)
+		}
+	}
+
+	// 3. Decoding restores the parts and their data.
+	var decoded agora.ChatMessage
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(decoded.Parts) != 5 || decoded.Text() != "What is in this screenshot?" {
+		t.Fatalf("unexpected decoded message %+v", decoded)
+	}
+	if p := decoded.Parts[1]; p.Type != agora.PartImage || p.MIMEType != "image/png" || !bytes.Equal(p.Data, png) || p.URL != "" {
+		t.Errorf("unexpected image part %+v", p)
+	}
+	if p := decoded.Parts[3]; p.Type != agora.PartFile || p.Filename != "report.pdf" || string(p.Data) != "%PDF-1.4" {
+		t.Errorf("unexpected file part %+v", p)
+	}
+
+	// 4. String and null content still decode.
+	for in, want := range map[string]string{
+		`{"role":"assistant","content":"hello"}`: "hello",
+		`{"role":"assistant","content":null}`:    "",
+	} {
+		var m agora.ChatMessage
+		if err := json.Unmarshal([]byte(in), &m); err != nil {
+			t.Fatalf("unexpected error for %s: %v", in, err)
+		}
+		if m.Content != want || m.Parts != nil {
+			t.Errorf("unexpected message %+v for %s", m, in)
+		}
+	}
+}
+
+// TestOllama_ContentParts verifies remote images are sent inline, text files
+// as text, and other files are rejected.
+func TestOllama_ContentParts(t *testing.T) {
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cat.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("cat pixels"))
+		case "/chat/completions":
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &sent)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"a cat"}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	model := llm.NewOllamaLLM(server.URL, "llava")
+
+	msg := agora.NewMultimodalMessage("user",
+		agora.TextPart("Describe these."),
+		agora.ImageURLPart(server.URL+"/cat.png"),
+		agora.FileDataPart("notes.txt", "text/plain", []byte("meow")),
+	)
+	resp, err := model.Invoke(context.Background(), agora.ModelRequest{Messages: []agora.ChatMessage{msg}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "a cat" {
+		t.Errorf("unexpected reply %+v", resp)
+	}
+	data, _ := json.Marshal(sent["messages"])
+	if want := agora.DataURL("image/png", []byte("cat pixels")); !strings.Contains(string(data), want) {
+		t.Errorf("expected the image inline, got %s", data)
+	}
+	if !strings.Contains(string(data), `notes.txt:\nmeow`) || strings.Contains(string(data), `"file"`) {
+		t.Errorf("expected the text file as text, got %s", data)
+	}
+
+	pdf := agora.NewMultimodalMessage("user", agora.FileDataPart("report.pdf", "application/pdf", []byte("%PDF")))
+	if _, err := model.Invoke(context.Background(), agora.ModelRequest{Messages: []agora.ChatMessage{pdf}}); err == nil {
+		t.Error("expected a PDF to be rejected")
+	}
+}
+
+// TestRedactingLLM_ContentParts verifies text parts are masked while images
+// pass through untouched.
+func TestRedactingLLM_ContentParts(t *testing.T) {
+	var seen agora.ChatMessage
+	mock := &MockLLM{InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+		seen = request.Messages[0]
+		return agora.ModelResponse{Choices: []agora.Choice{{Message: agora.ChatMessage{Role: "assistant", Content: "ok"}}}}, nil
+	}}
+	model := llm.NewRedactingLLM(mock, redact.New())
+
+	image := []byte("ada@example.com")
+	msg := agora.NewMultimodalMessage("user", agora.TextPart("mail ada@example.com"), agora.ImageDataPart("image/png", image))
+	if _, err := model.Invoke(context.Background(), agora.ModelRequest{Messages: []agora.ChatMessage{msg}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seen.Parts[0].Text != "mail [EMAIL_1]" || !bytes.Equal(seen.Parts[1].Data, image) {
+		t.Errorf("unexpected parts sent %+v", seen.Parts)
+	}
+	if msg.Parts[0].Text != "mail ada@example.com" {
+		t.Errorf("the caller's message was modified: %+v", msg.Parts)
+	}
+}
+
+// TestEstimateTokens_Parts verifies text parts are counted even when Content
+// is empty, and media parts at a flat rate.
+func TestEstimateTokens_Parts(t *testing.T) {
+	text := strings.Repeat("word ", 400)
+	msg := agora.ChatMessage{Role: "user", Parts: []agora.ContentPart{agora.TextPart(text)}}
+	if got := llm.EstimateTokens(agora.ModelRequest{Messages: []agora.ChatMessage{msg}}); got < 500 {
+		t.Errorf("expected the text part to be counted, got %d tokens", got)
+	}
+	msg.Parts = append(msg.Parts, agora.ImageDataPart("image/png", []byte("x")))
+	if got := llm.EstimateTokens(agora.ModelRequest{Messages: []agora.ChatMessage{msg}}); got < 1500 {
+		t.Errorf("expected the image to be counted, got %d tokens", got)
+	}
+}
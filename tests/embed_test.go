@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+// embeddingServer stands in for an /embeddings endpoint. Each text embeds to
+// [length, first byte, 1], and results come back in reverse order like some
+// providers do.
+func embeddingServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+		requests.Add(1)
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var data []item
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			text := req.Input[i]
+			first := float32(0)
+			if text != "" {
+				first = float32(text[0])
+			}
+			data = append(data, item{Index: i, Embedding: []float32{float32(len(text)), first, 1}})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestOpenAICompatibleEmbedder_Batching verifies batching, ordering and the
+// learned dimensions.
+func TestOpenAICompatibleEmbedder_Batching(t *testing.T) {
+	var requests atomic.Int32
+	server := embeddingServer(t, &requests)
+
+	embedder := llm.NewOpenAICompatibleEmbedder(server.URL, "embed-model", "")
+	embedder.BatchSize = 2
+	if embedder.Dimensions() != 0 {
+		t.Errorf("expected unknown dimensions before the first call, got %d", embedder.Dimensions())
+	}
+
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	vectors, err := embedder.Embed(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("expected 3 batches, got %d", requests.Load())
+	}
+	if len(vectors) != len(texts) {
+		t.Fatalf("expected %d vectors, got %d", len(texts), len(vectors))
+	}
+	for i, text := range texts {
+		if vectors[i][0] != float32(len(text)) || vectors[i][1] != float32(text[0]) {
+			t.Errorf("vector %d out of order: %v", i, vectors[i])
+		}
+	}
+	if embedder.Dimensions() != 3 {
+		t.Errorf("expected 3 dimensions, got %d", embedder.Dimensions())
+	}
+}
+
+// TestOpenAICompatibleEmbedder_Errors verifies provider errors surface as
+// StatusError so retry policies apply.
+func TestOpenAICompatibleEmbedder_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := llm.NewOpenAICompatibleEmbedder(server.URL, "embed-model", "").Embed(context.Background(), []string{"x"})
+	var statusErr *agora.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests || !agora.IsRetryable(err) {
+		t.Errorf("expected a retryable 429 StatusError, got %v", err)
+	}
+}
+
+// TestOllamaEmbedder verifies the Ollama embedder delegates to its client.
+func TestOllamaEmbedder(t *testing.T) {
+	var requests atomic.Int32
+	server := embeddingServer(t, &requests)
+
+	var embedder llm.Embedder = llm.NewOllamaEmbedder(server.URL, "")
+	vectors, err := embedder.Embed(context.Background(), []string{"hello"})
+	if err != nil || len(vectors) != 1 || vectors[0][0] != 5 {
+		t.Fatalf("unexpected result %v (%v)", vectors, err)
+	}
+	if embedder.Dimensions() != 3 {
+		t.Errorf("expected 3 dimensions, got %d", embedder.Dimensions())
+	}
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+// TestMapNode_OrderAndConcurrency verifies that results are reduced in input
+// order and that no more than MaxConcurrency branches run at once.
+func TestMapNode_OrderAndConcurrency(t *testing.T) {
+	var running, peak int32
+	worker := func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		s.Set("output", fmt.Sprintf("seen:%v", s.Get("doc")))
+		return agora.NodeResult{State: s}, nil
+	}
+
+	g := agora.NewGraph()
+	g.SetEntry("map")
+	g.AddNode("map", nodes.MapNode(worker, nodes.MapConfig{
+		ItemsKey:       "docs",
+		ItemKey:        "doc",
+		TargetKey:      "summaries",
+		MaxConcurrency: 2,
+	}))
+
+	state := newTestState()
+	state.Set("docs", []string{"a", "b", "c", "d", "e"})
+
+	finalState, err := g.Execute(context.Background(), state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	results, ok := finalState.Get("summaries").([]any)
+	if !ok || len(results) != 5 {
+		t.Fatalf("expected 5 results, got %#v", finalState.Get("summaries"))
+	}
+	for i, want := range []string{"a", "b", "c", "d", "e"} {
+		if results[i] != "seen:"+want {
+			t.Errorf("result %d: expected seen:%s, got %v", i, want, results[i])
+		}
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 concurrent branches, saw %d", peak)
+	}
+	if finalState.Get("doc") != nil {
+		t.Error("expected branch item not to leak into the original state")
+	}
+}
+
+// TestMapNode_Error verifies that a failing branch fails the whole node.
+func TestMapNode_Error(t *testing.T) {
+	boom := errors.New("boom")
+	worker := func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		if s.Get("n") == 2 {
+			return agora.NodeResult{State: s}, boom
+		}
+		return agora.NodeResult{State: s}, nil
+	}
+
+	node := nodes.MapNode(worker, nodes.MapConfig{ItemsKey: "nums", ItemKey: "n"})
+
+	state := newTestState()
+	state.Set("nums", []int{1, 2, 3})
+
+	_, err := node(context.Background(), state)
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom error, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/memory"
+	"github.com/amangsingh/agora/nodes"
+	"github.com/amangsingh/agora/vectorstore"
+)
+
+// TestThread_ContinuesConversation verifies a second run sees the first
+// run's turns and only new turns are saved.
+func TestThread_ContinuesConversation(t *testing.T) {
+	ctx := context.Background()
+	var seen [][]agora.ChatMessage
+	mock := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			seen = append(seen, request.Messages)
+			reply := agora.ChatMessage{Role: "assistant", Content: "reply"}
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+		},
+	}
+	g := agora.NewGraph()
+	g.AddNode("agent", nodes.SimpleAgentNode(mock, "Be brief."))
+	g.SetEntry("agent")
+
+	store := memory.NewMemoryThreadStore()
+	if _, err := memory.NewThread(store, "t1").Run(ctx, g, "first"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	final, err := memory.NewThread(store, "t1").Run(ctx, g, "second")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// System prompt, first turn, then the new input.
+	if got := seen[1]; len(got) != 4 || got[1].Content != "first" || got[3].Content != "second" {
+		t.Errorf("expected the first turn in the second request, got %+v", got)
+	}
+	if final.Get("thread_id") != "t1" {
+		t.Errorf("expected thread_id in state, got %v", final.Get("thread_id"))
+	}
+	messages, _ := store.LoadThread(ctx, "t1", 0)
+	if len(messages) != 4 {
+		t.Errorf("expected 4 messages in the thread, got %d", len(messages))
+	}
+	if other, _ := store.LoadThread(ctx, "t2", 0); len(other) != 0 {
+		t.Errorf("expected other threads to be empty, got %+v", other)
+	}
+}
+
+// TestThread_MaxHistory verifies a capped history never starts with a tool
+// result whose call was cut off.
+func TestThread_MaxHistory(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryThreadStore()
+	store.AppendThread(ctx, "t1",
+		agora.ChatMessage{Role: "user", Content: "look it up"},
+		agora.ChatMessage{Role: "assistant", ToolCalls: []agora.ToolCall{{ID: "1"}}},
+		agora.ChatMessage{Role: "tool", Content: "result", ToolCallID: "1"},
+		agora.ChatMessage{Role: "assistant", Content: "found it"},
+	)
+
+	thread := &memory.Thread{ID: "t1", Store: store, MaxHistory: 2}
+	s, err := thread.State(ctx, "next")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.History) != 1 || s.History[0].Content != "found it" {
+		t.Errorf("unexpected history %+v", s.History)
+	}
+
+	// Only what the run adds is saved.
+	s.AppendTurn(agora.ChatMessage{Role: "assistant", Content: "done"})
+	if err := thread.Save(ctx, s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	messages, _ := store.LoadThread(ctx, "t1", 0)
+	if len(messages) != 6 || messages[4].Content != "next" {
+		t.Errorf("unexpected thread %+v", messages)
+	}
+}
+
+// TestFacts verifies facts are deduplicated, searched by similarity and kept
+// apart by scope.
+func TestFacts(t *testing.T) {
+	ctx := context.Background()
+	store := vectorstore.NewMemoryStore(vectorstore.Cosine)
+	ada := memory.NewFacts(letterEmbedder{}, store, "ada")
+	bob := memory.NewFacts(letterEmbedder{}, store, "bob")
+
+	id, err := ada.Remember(ctx, "Prefers tea over coffee")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, _ := ada.Remember(ctx, "prefers tea over coffee "); again != id {
+		t.Errorf("expected the same fact to keep its ID, got %s and %s", id, again)
+	}
+	ada.Remember(ctx, "Lives in Zurich")
+	bob.Remember(ctx, "Prefers tea over coffee")
+	if _, err := ada.Remember(ctx, "  "); err == nil {
+		t.Error("expected an empty fact to be rejected")
+	}
+
+	facts, err := ada.Search(ctx, "tea or coffee?", 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(facts) != 2 || facts[0].ID != id || facts[0].RememberedAt == "" {
+		t.Errorf("unexpected facts %+v", facts)
+	}
+
+	if err := ada.Forget(ctx, id); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if facts, _ := ada.Search(ctx, "tea", 5); len(facts) != 1 {
+		t.Errorf("expected one fact left, got %+v", facts)
+	}
+}
+
+// TestFacts_Tools verifies the built-in tools write and search facts.
+func TestFacts_Tools(t *testing.T) {
+	ctx := context.Background()
+	facts := memory.NewFacts(letterEmbedder{}, vectorstore.NewMemoryStore(vectorstore.Cosine), "ada")
+	registry := agora.NewToolRegistry()
+	registry.RegisterAll(facts.Tools()...)
+	if _, ok := registry["remember_fact"]; !ok {
+		t.Fatal("expected remember_fact to be registered")
+	}
+
+	remember := registry["remember_fact"]
+	if _, err := remember.Execute(ctx, map[string]interface{}{"fact": "Allergic to peanuts"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, err := registry["search_facts"].Execute(ctx, map[string]interface{}{"query": "peanuts", "limit": float64(1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	found := result.(map[string]any)["facts"].([]memory.Fact)
+	if len(found) != 1 || found[0].Text != "Allergic to peanuts" {
+		t.Errorf("unexpected search result %+v", found)
+	}
+	if _, err := registry["search_facts"].Execute(ctx, map[string]interface{}{}); err == nil {
+		t.Error("expected a missing query to be rejected")
+	}
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+// recordingMiddleware appends "<label>:<node>" to calls on every execution.
+func recordingMiddleware(label string, calls *[]string) agora.Middleware {
+	return func(name string, next agora.NodeFunc) agora.NodeFunc {
+		return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+			*calls = append(*calls, label+":"+name)
+			return next(ctx, s)
+		}
+	}
+}
+
+// TestGraph_Use_Order verifies that middleware wraps every node and that the
+// first registered middleware is the outermost.
+func TestGraph_Use_Order(t *testing.T) {
+	var calls []string
+	g := agora.NewGraph()
+	g.SetEntry("a")
+	g.AddNode("a", passThrough)
+	g.AddNode("b", passThrough)
+	g.AddEdge("a", "b")
+	g.Use(recordingMiddleware("outer", &calls), recordingMiddleware("inner", &calls))
+
+	if _, err := g.Execute(context.Background(), newTestState()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"outer:a", "inner:a", "outer:b", "inner:b"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected %v, got %v", expected, calls)
+	}
+}
+
+// TestGraph_Use_Inherit verifies that middleware only reaches sub-graph nodes
+// when InheritMiddleware is set.
+func TestGraph_Use_Inherit(t *testing.T) {
+	for _, inherit := range []bool{false, true} {
+		var calls []string
+		child := agora.NewGraph()
+		child.SetEntry("inner")
+		child.AddNode("inner", passThrough)
+
+		parent := agora.NewGraph()
+		parent.SetEntry("child")
+		parent.AddNode("child", nodes.SubGraphNode(child))
+		parent.Use(recordingMiddleware("mw", &calls))
+		parent.InheritMiddleware = inherit
+
+		if _, err := parent.Execute(context.Background(), newTestState()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := []string{"mw:child"}
+		if inherit {
+			expected = append(expected, "mw:inner")
+		}
+		if !reflect.DeepEqual(calls, expected) {
+			t.Errorf("inherit=%v: expected %v, got %v", inherit, expected, calls)
+		}
+	}
+}
+
+// TestRecover_PanicBecomesError verifies that a panic is converted into a
+// PanicError that can trigger a fallback.
+func TestRecover_PanicBecomesError(t *testing.T) {
+	g := agora.NewGraph()
+	g.SetEntry("explode")
+	g.AddNode("explode", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		panic("kaboom")
+	})
+	g.Use(agora.Recover())
+
+	_, err := g.Execute(context.Background(), newTestState())
+	var panicErr *agora.PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected a PanicError, got %v", err)
+	}
+	if panicErr.Node != "explode" || panicErr.Value != "kaboom" || len(panicErr.Stack) == 0 {
+		t.Errorf("unexpected panic error %+v", panicErr)
+	}
+}
+
+// TestLogging_And_Timing verifies the logging and timing middlewares.
+func TestLogging_And_Timing(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	timed := map[string]time.Duration{}
+
+	g := agora.NewGraph()
+	g.SetEntry("work")
+	g.AddNode("work", passThrough)
+	g.Use(agora.Logging(logger), agora.Timing(func(name string, d time.Duration, err error) {
+		timed[name] = d
+	}))
+
+	if _, err := g.Execute(context.Background(), newTestState()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `msg="node started" node=work`) || !strings.Contains(out, `msg="node finished" node=work`) {
+		t.Errorf("expected start and finish log lines, got:\n%s", out)
+	}
+	if _, ok := timed["work"]; !ok {
+		t.Errorf("expected timing for node 'work', got %v", timed)
+	}
+}
+
+// TestAssertState verifies that a violated invariant fails the node.
+func TestAssertState(t *testing.T) {
+	g := agora.NewGraph()
+	g.SetEntry("corrupt")
+	g.AddNode("corrupt", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		s.Set("count", -1)
+		return agora.NodeResult{State: s}, nil
+	})
+	g.Use(agora.AssertState(func(s agora.State) error {
+		if v, ok := s.Get("count").(int); ok && v < 0 {
+			return fmt.Errorf("count must not be negative, got %v", v)
+		}
+		return nil
+	}))
+
+	_, err := g.Execute(context.Background(), newTestState())
+	if err == nil || !strings.Contains(err.Error(), "state invariant violated after node corrupt") {
+		t.Fatalf("expected invariant violation, got %v", err)
+	}
+
+	// A node without a result state fails before the check runs.
+	g.AddNode("corrupt", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		return agora.NodeResult{}, nil
+	})
+	_, err = g.Execute(context.Background(), newTestState())
+	if err == nil || !strings.Contains(err.Error(), "node returned no state") {
+		t.Fatalf("expected a missing state error, got %v", err)
+	}
+}
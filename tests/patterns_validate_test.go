@@ -0,0 +1,26 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+// TestPatternGraphs_Validate verifies that the graphs behind the built-in
+// patterns declare all of their transitions.
+func TestPatternGraphs_Validate(t *testing.T) {
+	mock := &MockLLM{}
+	graphs := map[string]*agora.Graph{
+		"reflection": nodes.NewReflectionGraph(nodes.ReflectionConfig{Generator: passThrough, Critic: passThrough}),
+		"plan":       nodes.NewPlanExecuteGraph(nodes.PlanExecuteConfig{Planner: mock, Executor: mock}),
+		"supervisor": nodes.NewSupervisorGraph(mock, nodes.SupervisorConfig{
+			Workers: []nodes.Worker{{Name: "researcher", Node: passThrough}},
+		}),
+	}
+	for name, g := range graphs {
+		if err := g.Validate(); err != nil {
+			t.Errorf("%s graph is invalid: %v", name, err)
+		}
+	}
+}
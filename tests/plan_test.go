@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+func argsCall(name string, args map[string]interface{}) agora.ChatMessage {
+	call := agora.ToolCall{ID: "call_" + name, Type: "function"}
+	call.Function.Name = name
+	call.Function.Arguments = args
+	return agora.ChatMessage{Role: "assistant", ToolCalls: []agora.ToolCall{call}}
+}
+
+func TestPlanExecuteNode(t *testing.T) {
+	planner := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			reply := argsCall("submit_plan", map[string]interface{}{
+				"goal":  "write a report",
+				"steps": []interface{}{"research", "draft"},
+			})
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+		},
+	}
+	executed := 0
+	executor := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			executed++
+			reply := agora.ChatMessage{Role: "assistant", Content: fmt.Sprintf("result %d", executed)}
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+		},
+	}
+	replans := 0
+	replanner := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			replans++
+			// First revise the remaining steps, then finish.
+			reply := argsCall("update_plan", map[string]interface{}{"steps": []interface{}{"draft carefully"}})
+			if replans == 2 {
+				reply = argsCall("finish", map[string]interface{}{"answer": "the report"})
+			}
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+		},
+	}
+
+	g := agora.NewGraph()
+	g.SetEntry("planner")
+	g.AddNode("planner", nodes.PlanExecuteNode(nodes.PlanExecuteConfig{
+		Planner:   planner,
+		Replanner: replanner,
+		Executor:  executor,
+		Tools:     agora.NewToolRegistry(),
+	}))
+
+	finalState, err := g.Execute(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	plan, ok, err := nodes.PlanFromState(finalState)
+	if err != nil || !ok {
+		t.Fatalf("expected a typed plan in state, got %v (err %v)", finalState.Get("plan"), err)
+	}
+	if len(plan.Steps) != 2 {
+		t.Fatalf("expected 2 steps after replanning, got %+v", plan.Steps)
+	}
+	if plan.Steps[1].Description != "draft carefully" {
+		t.Errorf("expected replanned second step, got %q", plan.Steps[1].Description)
+	}
+	for i, step := range plan.Steps {
+		if step.Status != nodes.StepDone || step.Result != fmt.Sprintf("result %d", i+1) {
+			t.Errorf("step %d: expected done with result, got %+v", i, step)
+		}
+	}
+	if got := finalState.Get("output"); got != "the report" {
+		t.Errorf("expected the replanner's answer as output, got %v", got)
+	}
+	history := finalState.(*agora.ConversationState).History
+	if len(history) != 2 || history[1].Content != "the report" {
+		t.Errorf("expected only the final answer in parent history, got %+v", history)
+	}
+}
+
+// TestPlanExecuteNode_StepOutOfSteps verifies a step that runs out of steps
+// stops the run instead of being recorded as failed and replanned.
+func TestPlanExecuteNode_StepOutOfSteps(t *testing.T) {
+	planner := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			reply := argsCall("submit_plan", map[string]interface{}{"goal": "count", "steps": []interface{}{"count forever"}})
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+		},
+	}
+	executor := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: argsCall("count", nil)}}}, nil
+		},
+	}
+	replans := 0
+	replanner := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			replans++
+			reply := argsCall("finish", map[string]interface{}{"answer": "gave up"})
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+		},
+	}
+	tools := agora.NewToolRegistry()
+	tools.Register(&countingTool{})
+
+	g := agora.NewGraph()
+	g.SetEntry("planner")
+	g.AddNode("planner", nodes.PlanExecuteNode(nodes.PlanExecuteConfig{
+		Planner:          planner,
+		Replanner:        replanner,
+		Executor:         executor,
+		Tools:            tools,
+		ExecutorMaxSteps: 4,
+	}))
+
+	_, err := g.Execute(context.Background(), newTestState())
+	if !errors.Is(err, agora.ErrMaxStepsExceeded) {
+		t.Fatalf("expected the step's max steps error, got %v", err)
+	}
+	if replans != 0 {
+		t.Errorf("expected no replanning after the step ran out of steps, got %d", replans)
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+)
+
+func noBackoff(int) time.Duration { return 0 }
+
+// TestNodePolicy_RetryRestoresState verifies that a retried node starts from
+// the pre-step state rather than whatever the failed attempt left behind.
+func TestNodePolicy_RetryRestoresState(t *testing.T) {
+	attempts := 0
+	g := agora.NewGraph()
+	g.SetEntry("flaky")
+	g.AddNode("flaky", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		attempts++
+		if s.Get("dirty") != nil {
+			t.Errorf("attempt %d saw state from a failed attempt", attempts)
+		}
+		s.Set("dirty", true)
+		if attempts < 3 {
+			return agora.NodeResult{State: s}, &agora.StatusError{StatusCode: 503}
+		}
+		return agora.NodeResult{State: s, IsDone: true}, nil
+	}, agora.WithRetry(3, noBackoff))
+
+	finalState, err := g.Execute(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	if finalState.Get("dirty") != true {
+		t.Error("expected the successful attempt's state to be kept")
+	}
+}
+
+// TestNodePolicy_NonRetryable verifies that permanent errors are not retried.
+func TestNodePolicy_NonRetryable(t *testing.T) {
+	attempts := 0
+	g := agora.NewGraph()
+	g.SetEntry("bad")
+	g.AddNode("bad", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		attempts++
+		return agora.NodeResult{State: s}, &agora.StatusError{StatusCode: 400}
+	}, agora.WithRetry(5, noBackoff))
+
+	_, err := g.Execute(context.Background(), newTestState())
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if attempts != 1 {
+		t.Errorf("expected a single attempt for a 400, got %d", attempts)
+	}
+}
+
+// TestNodePolicy_TimeoutFallback verifies that a timed-out node routes to its
+// fallback with the error recorded in state.
+func TestNodePolicy_TimeoutFallback(t *testing.T) {
+	g := agora.NewGraph()
+	g.SetEntry("slow")
+	g.AddNode("slow", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		s.Set("partial", true)
+		<-ctx.Done()
+		return agora.NodeResult{State: s}, ctx.Err()
+	}, agora.WithTimeout(5*time.Millisecond), agora.WithFallback("apologize"))
+	g.AddNode("apologize", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		s.Set("output", "sorry")
+		return agora.NodeResult{State: s, IsDone: true}, nil
+	})
+
+	finalState, err := g.Execute(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := finalState.Get("output"); got != "sorry" {
+		t.Errorf("expected fallback output, got %v", got)
+	}
+	if got := finalState.Get("error_node"); got != "slow" {
+		t.Errorf("expected error_node slow, got %v", got)
+	}
+	if msg, _ := finalState.Get("error").(string); msg == "" {
+		t.Error("expected the error to be stored in state")
+	}
+	if finalState.Get("partial") != nil {
+		t.Error("expected the fallback to start from the pre-step state")
+	}
+}
+
+// TestIsRetryable covers the default error classification.
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&agora.StatusError{StatusCode: 429}, true},
+		{&agora.StatusError{StatusCode: 502}, true},
+		{&agora.StatusError{StatusCode: 401}, false},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{errors.New("LLM returned no choices"), false},
+	}
+	for _, c := range cases {
+		if got := agora.IsRetryable(c.err); got != c.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+	"github.com/amangsingh/agora/rag"
+	"github.com/amangsingh/agora/vectorstore"
+)
+
+// letterEmbedder embeds text as its letter frequencies: crude, but
+// deterministic, and texts sharing words score higher.
+type letterEmbedder struct{}
+
+func (letterEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, 26)
+		for _, r := range strings.ToLower(text) {
+			if r >= 'a' && r <= 'z' {
+				v[r-'a']++
+			}
+		}
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+func (letterEmbedder) Dimensions() int { return 26 }
+
+// TestLoadHTML verifies scripts, styles and tags are stripped, entities
+// decoded, and headings kept as Markdown.
+func TestLoadHTML(t *testing.T) {
+	page := `<html><head><title>Guide &amp; FAQ</title><style>p{}</style></head>
+<body><script>alert(1)</script><h2 class="x">Setup</h2><p>Run   <b>make</b>&nbsp;install.</p><!-- hidden --><ul><li>One</li><li>Two</li></ul></body></html>`
+	src, err := rag.LoadHTML("guide.html", strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "## Setup\n\nRun make install.\n\nOne\nTwo"
+	if src.Text != want {
+		t.Errorf("expected %q, got %q", want, src.Text)
+	}
+	if src.Metadata["title"] != "Guide & FAQ" {
+		t.Errorf("unexpected title %q", src.Metadata["title"])
+	}
+}
+
+// TestSplit verifies size limits, overlap, word boundaries and headings.
+func TestSplit(t *testing.T) {
+	text := strings.Repeat("alpha beta gamma delta ", 20) // 460 characters
+	chunks := rag.Split(rag.Source{ID: "doc", Text: text}, rag.ChunkConfig{Size: 100, Overlap: 30})
+	if len(chunks) < 5 {
+		t.Fatalf("expected at least 5 chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if len(c.Text) > 100 {
+			t.Errorf("chunk %d is %d characters", i, len(c.Text))
+		}
+		for _, word := range strings.Fields(c.Text) {
+			if !strings.Contains("alpha beta gamma delta", word) {
+				t.Errorf("chunk %d splits a word: %q", i, word)
+			}
+		}
+	}
+	// The tail of each chunk reappears at the head of the next.
+	firstWords := strings.Fields(chunks[1].Text)[:2]
+	if !strings.Contains(chunks[0].Text, strings.Join(firstWords, " ")) {
+		t.Errorf("expected chunks to overlap: %q / %q", chunks[0].Text, chunks[1].Text)
+	}
+	if chunks[2].ID != "doc#2" || chunks[2].Metadata["source"] != "doc" || chunks[2].Metadata["chunk"] != "2" {
+		t.Errorf("unexpected chunk identity: %+v", chunks[2])
+	}
+
+	md := "Intro text.\n# Install\nRun it.\n## Linux\nUse apt.\n"
+	chunks = rag.Split(rag.Source{ID: "md", Text: md}, rag.ChunkConfig{Headings: true})
+	var headings []string
+	for _, c := range chunks {
+		headings = append(headings, c.Metadata["heading"])
+	}
+	if !reflect.DeepEqual(headings, []string{"", "Install", "Linux"}) || chunks[2].Text != "## Linux\nUse apt." {
+		t.Errorf("unexpected heading chunks: %+v", chunks)
+	}
+}
+
+// TestIngestAndAnswer ingests files, retrieves for a question and verifies
+// the answer carries the IDs of the chunks it cited.
+func TestIngestAndAnswer(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "zebra.md"), []byte("# Zebras\nZebras have black and white stripes."), 0644)
+	os.WriteFile(filepath.Join(dir, "kiwi.txt"), []byte("Kiwis are flightless birds from New Zealand."), 0644)
+	os.WriteFile(filepath.Join(dir, "ignored.bin"), []byte{0, 1, 2}, 0644)
+
+	sources, err := rag.LoadPaths(dir)
+	if err != nil || len(sources) != 2 {
+		t.Fatalf("expected 2 sources, got %d (%v)", len(sources), err)
+	}
+	store := vectorstore.NewMemoryStore(vectorstore.Cosine)
+	ingester := rag.NewIngester(letterEmbedder{}, store)
+	if n, err := ingester.Ingest(ctx, sources...); err != nil || n != 2 {
+		t.Fatalf("expected 2 chunks, got %d (%v)", n, err)
+	}
+
+	var prompt string
+	mockLLM := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			prompt = request.Messages[0].Content
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: agora.ChatMessage{Role: "assistant", Content: "They are striped [1], see also [1] and [9]."}}}}, nil
+		},
+	}
+	g := agora.NewGraph()
+	g.SetEntry("retrieve")
+	g.AddNode("retrieve", nodes.RetrieverNode(nodes.RetrieverConfig{Embedder: letterEmbedder{}, Store: store, TopK: 1}))
+	g.AddNode("answer", nodes.RAGAgentNode(mockLLM, "You answer questions about animals."))
+	g.AddEdge("retrieve", "answer")
+
+	state := newTestState()
+	state.Input = "What stripes do zebras have?"
+	final, err := g.Execute(ctx, state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	zebra := filepath.Join(dir, "zebra.md") + "#0"
+	if !strings.Contains(prompt, "[1] (source: "+zebra+")") || !strings.Contains(prompt, "black and white") {
+		t.Errorf("expected the zebra chunk in the prompt, got %q", prompt)
+	}
+	if got := final.Get("sources"); !reflect.DeepEqual(got, []string{zebra}) {
+		t.Errorf("expected sources [%s], got %v", zebra, got)
+	}
+
+	// Re-ingesting a shorter version drops the stale chunks.
+	long := rag.Source{ID: "notes", Text: strings.Repeat("word ", 100)}
+	short := rag.Source{ID: "notes", Text: "word"}
+	ingester.Chunking = rag.ChunkConfig{Size: 50, Overlap: -1}
+	ingester.Ingest(ctx, long)
+	ingester.Ingest(ctx, short)
+	results, _ := store.Search(ctx, make([]float32, 26), 0, vectorstore.Filter{"source": "notes"})
+	if len(results) != 1 {
+		t.Errorf("expected 1 chunk after re-ingesting, got %d", len(results))
+	}
+
+	// Re-ingesting it empty drops the rest.
+	if n, err := ingester.Ingest(ctx, rag.Source{ID: "notes", Text: "  "}); err != nil || n != 0 {
+		t.Fatalf("expected an empty source to store nothing, got %d, %v", n, err)
+	}
+	results, _ = store.Search(ctx, make([]float32, 26), 0, vectorstore.Filter{"source": "notes"})
+	if len(results) != 0 {
+		t.Errorf("expected no chunks after re-ingesting an empty source, got %d", len(results))
+	}
+}
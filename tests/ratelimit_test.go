@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+// usageLLM reports the given token usage and counts its calls.
+func usageLLM(tokens int, calls *int) *MockLLM {
+	var mu sync.Mutex
+	return &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			mu.Lock()
+			*calls++
+			mu.Unlock()
+			return agora.ModelResponse{Usage: agora.Usage{TotalTokens: tokens}}, nil
+		},
+	}
+}
+
+// TestRateLimitedLLM_Requests verifies calls beyond the request allowance are
+// paced by the refill rate, across goroutines.
+func TestRateLimitedLLM_Requests(t *testing.T) {
+	var calls int
+	limiter := llm.NewRateLimiter(llm.RateLimitConfig{RequestsPerMinute: 2, Interval: 100 * time.Millisecond})
+	model := llm.NewRateLimitedLLM(usageLLM(0, &calls), limiter)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := model.Invoke(context.Background(), agora.ModelRequest{}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Two calls burst, the other two wait 50ms each.
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected pacing of about 100ms, took %v", elapsed)
+	}
+	if calls != 4 {
+		t.Errorf("expected 4 calls, got %d", calls)
+	}
+}
+
+// TestRateLimitedLLM_TokenReconciliation verifies reported usage replaces the
+// estimate, and that the estimate stands when no usage is reported.
+func TestRateLimitedLLM_TokenReconciliation(t *testing.T) {
+	cfg := llm.RateLimitConfig{TokensPerMinute: 100, Interval: 200 * time.Millisecond}
+	estimate := func(agora.ModelRequest) int { return 100 }
+	twoCalls := func(usage int) time.Duration {
+		var calls int
+		model := llm.NewRateLimitedLLM(usageLLM(usage, &calls), llm.NewRateLimiter(cfg))
+		model.Estimate = estimate
+		start := time.Now()
+		model.Invoke(context.Background(), agora.ModelRequest{})
+		model.Invoke(context.Background(), agora.ModelRequest{})
+		return time.Since(start)
+	}
+
+	// The first call is refunded down to 10 tokens, so the second fits at once.
+	if elapsed := twoCalls(10); elapsed > 100*time.Millisecond {
+		t.Errorf("expected the refund to admit the second call, took %v", elapsed)
+	}
+	// Without usage the bucket stays empty until it refills.
+	if elapsed := twoCalls(0); elapsed < 150*time.Millisecond {
+		t.Errorf("expected the second call to wait for a refill, took %v", elapsed)
+	}
+}
+
+// TestRateLimitedLLM_ContextCancel verifies a waiting call gives up with its
+// context and never reaches the model.
+func TestRateLimitedLLM_ContextCancel(t *testing.T) {
+	var calls int
+	model := llm.NewRateLimitedLLM(usageLLM(0, &calls), llm.NewRateLimiter(llm.RateLimitConfig{RequestsPerMinute: 1}))
+	model.Invoke(context.Background(), agora.ModelRequest{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := model.Invoke(ctx, agora.ModelRequest{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected only the first call to reach the model, got %d", calls)
+	}
+}
+
+// TestRateLimiter_FIFO verifies a large request queued first is served before
+// smaller ones that would fit sooner.
+func TestRateLimiter_FIFO(t *testing.T) {
+	limiter := llm.NewRateLimiter(llm.RateLimitConfig{TokensPerMinute: 100, Interval: 100 * time.Millisecond})
+	limiter.Wait(context.Background(), 100) // drain the bucket
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	wait := func(name string, tokens int) {
+		defer wg.Done()
+		limiter.Wait(context.Background(), tokens)
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+	}
+	wg.Add(2)
+	go wait("large", 80)
+	time.Sleep(10 * time.Millisecond)
+	go wait("small", 1)
+	wg.Wait()
+
+	if len(order) != 2 || order[0] != "large" {
+		t.Errorf("expected the large request first, got %v", order)
+	}
+}
+
+// TestSharedRateLimiter verifies limiters are shared by name.
+func TestSharedRateLimiter(t *testing.T) {
+	a := llm.SharedRateLimiter("test-provider", llm.RateLimitConfig{RequestsPerMinute: 10})
+	b := llm.SharedRateLimiter("test-provider", llm.RateLimitConfig{RequestsPerMinute: 99})
+	if a != b {
+		t.Error("expected the same limiter for the same name")
+	}
+	if llm.SharedRateLimiter("other-provider", llm.RateLimitConfig{}) == a {
+		t.Error("expected a different limiter for a different name")
+	}
+}
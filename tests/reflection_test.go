@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+func verdictCall(decision, feedback string) agora.ChatMessage {
+	call := agora.ToolCall{ID: "call_verdict", Type: "function"}
+	call.Function.Name = "submit_verdict"
+	call.Function.Arguments = map[string]interface{}{"decision": decision, "feedback": feedback}
+	return agora.ChatMessage{Role: "assistant", ToolCalls: []agora.ToolCall{call}}
+}
+
+// TestReflectionNode_RevisesUntilApproved runs LLM generator and critic nodes
+// through two rounds: the first draft is rejected, the second approved.
+func TestReflectionNode_RevisesUntilApproved(t *testing.T) {
+	drafts := 0
+	generator := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			drafts++
+			if drafts == 2 && !strings.Contains(request.Messages[0].Content, "add a title") {
+				t.Errorf("expected revision prompt to carry the feedback, got %q", request.Messages[0].Content)
+			}
+			reply := agora.ChatMessage{Role: "assistant", Content: fmt.Sprintf("draft %d", drafts)}
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+		},
+	}
+	critiques := 0
+	critic := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			critiques++
+			reply := verdictCall("revise", "add a title")
+			if critiques == 2 {
+				reply = verdictCall("approve", "looks good")
+			}
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+		},
+	}
+
+	g := agora.NewGraph()
+	g.SetEntry("reflect")
+	g.AddNode("reflect", nodes.ReflectionNode(nodes.ReflectionConfig{
+		Generator: nodes.ReflectionGeneratorNode(generator, "Write a haiku."),
+		Critic:    nodes.CriticNode(critic, "You are a strict editor."),
+		MaxRounds: 5,
+	}))
+
+	finalState, err := g.Execute(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := finalState.Get("output"); got != "draft 2" {
+		t.Errorf("expected the approved second draft, got %v", got)
+	}
+	if got := finalState.Get("drafts").([]string); len(got) != 2 {
+		t.Errorf("expected 2 recorded drafts, got %v", got)
+	}
+	critiqueList := finalState.Get("critiques").([]nodes.Verdict)
+	if len(critiqueList) != 2 || !critiqueList[1].Approved() {
+		t.Errorf("expected a revise then an approve verdict, got %+v", critiqueList)
+	}
+	history := finalState.(*agora.ConversationState).History
+	if len(history) != 2 || history[1].Content != "draft 2" {
+		t.Errorf("expected only the final draft in history, got %+v", history)
+	}
+}
+
+// TestReflectionNode_RespectsParentBudget verifies that a never-satisfied
+// critic cannot run past the parent graph's MaxSteps: the loop finishes with
+// the last draft, and refuses to start without room for one round.
+func TestReflectionNode_RespectsParentBudget(t *testing.T) {
+	drafts := 0
+	generator := func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		drafts++
+		s.Set("output", fmt.Sprintf("draft %d", drafts))
+		return agora.NodeResult{State: s}, nil
+	}
+	critic := func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		s.Set("verdict", nodes.Verdict{Decision: nodes.VerdictRevise, Feedback: "again"})
+		return agora.NodeResult{State: s}, nil
+	}
+	reflect := nodes.ReflectionNode(nodes.ReflectionConfig{
+		Generator: generator,
+		Critic:    critic,
+		MaxRounds: 50,
+	})
+
+	g := agora.NewGraph()
+	g.MaxSteps = 8
+	g.SetEntry("reflect")
+	g.AddNode("reflect", reflect)
+
+	finalState, err := g.Execute(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("expected the loop to finish within the budget, got %v", err)
+	}
+	history := finalState.(*agora.ConversationState).History
+	if drafts != 2 || len(history) != 2 || history[1].Content != "draft 2" {
+		t.Errorf("expected 2 rounds ending with the last draft, got %d drafts and %+v", drafts, history)
+	}
+
+	g.MaxSteps = 3
+	if _, err := g.Execute(context.Background(), newTestState()); !errors.Is(err, agora.ErrMaxStepsExceeded) {
+		t.Fatalf("expected ErrMaxStepsExceeded without room for a round, got %v", err)
+	}
+}
+
+// TestReflectionNode_Keys verifies configured keys leave the caller's own
+// "verdict", "drafts" and "critiques" alone.
+func TestReflectionNode_Keys(t *testing.T) {
+	generator := &MockLLM{InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+		return agora.ModelResponse{Choices: []agora.Choice{{Message: agora.ChatMessage{Role: "assistant", Content: "draft"}}}}, nil
+	}}
+	critic := &MockLLM{InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+		return agora.ModelResponse{Choices: []agora.Choice{{Message: verdictCall("approve", "fine")}}}, nil
+	}}
+
+	g := agora.NewGraph()
+	g.SetEntry("reflect")
+	g.AddNode("reflect", nodes.ReflectionNode(nodes.ReflectionConfig{
+		Generator:    nodes.ReflectionGeneratorNode(generator, "Write."),
+		Critic:       nodes.CriticNode(critic, "Review."),
+		VerdictKey:   "essay_verdict",
+		DraftsKey:    "essay_drafts",
+		CritiquesKey: "essay_critiques",
+		RoundKey:     "essay_round",
+	}))
+
+	state := newTestState()
+	for _, key := range []string{"verdict", "drafts", "critiques", "reflection_round"} {
+		state.Set(key, "mine")
+	}
+	finalState, err := g.Execute(context.Background(), state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range []string{"verdict", "drafts", "critiques", "reflection_round"} {
+		if got := finalState.Get(key); got != "mine" {
+			t.Errorf("expected %s to be left alone, got %v", key, got)
+		}
+	}
+	if verdict, ok := finalState.Get("essay_verdict").(nodes.Verdict); !ok || !verdict.Approved() {
+		t.Errorf("expected the verdict under essay_verdict, got %v", finalState.Get("essay_verdict"))
+	}
+	if drafts, _ := finalState.Get("essay_drafts").([]string); len(drafts) != 1 {
+		t.Errorf("expected one draft under essay_drafts, got %v", finalState.Get("essay_drafts"))
+	}
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/amangsingh/agora"
+)
+
+func renderTestGraph() *agora.Graph {
+	child := agora.NewGraph()
+	child.SetEntry("search")
+	child.AddNode("search", passThrough)
+	child.AddEdge("search", agora.END)
+
+	g := agora.NewGraph()
+	g.SetEntry("router")
+	g.AddNode("router", passThrough, agora.WithRoutes("agent", "research"))
+	g.AddNode("agent", passThrough, agora.WithFallback("apologize"))
+	g.AddNode("research", passThrough, agora.WithSubGraph(child))
+	g.AddNode("apologize", passThrough)
+	g.AddNode("tools", passThrough)
+	g.SetConditionalEdge("agent", func(s agora.State) string { return agora.END }, "tools", agora.END)
+	g.AddEdge("tools", "agent")
+	g.AddEdge("research", "agent")
+	g.AddEdge("apologize", agora.END)
+	return g
+}
+
+func TestGraph_Mermaid(t *testing.T) {
+	out := renderTestGraph().Mermaid()
+
+	expected := []string{
+		"flowchart TD",
+		"n_____start____ --> n_router",
+		"n_router -.->|route| n_agent",
+		"n_router -.->|route| n_research_2f____start____",
+		"n_agent -.-> n_tools",
+		"n_agent -.-> n_____end____",
+		"n_agent -.->|error| n_apologize",
+		"n_tools --> n_agent",
+		`subgraph n_research ["research"]`,
+		"n_research_2f____start____ --> n_research_2fsearch",
+		"n_research_2fsearch --> n_research_2f____end____",
+		"n_research_2f____end____ --> n_agent",
+	}
+	for _, want := range expected {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected Mermaid output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestGraph_DOT(t *testing.T) {
+	out := renderTestGraph().DOT()
+
+	expected := []string{
+		"digraph agora {",
+		`"__start__" -> "router";`,
+		`"router" -> "research/__start__" [style=dashed, label="route"];`,
+		`"agent" -> "tools" [style=dashed];`,
+		`"agent" -> "apologize" [style=dashed, label="error"];`,
+		`"tools" -> "agent";`,
+		`subgraph "cluster_research" {`,
+		`"research/search" -> "research/__end__";`,
+		`"apologize" -> "__end__";`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected DOT output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "{") != strings.Count(out, "}") {
+		t.Errorf("expected balanced braces, got:\n%s", out)
+	}
+}
+
+// TestGraph_Mermaid_DistinctIDs verifies names differing only in characters
+// Mermaid does not allow in IDs still render as separate nodes.
+func TestGraph_Mermaid_DistinctIDs(t *testing.T) {
+	g := agora.NewGraph()
+	g.SetEntry("a-b")
+	g.AddNode("a-b", passThrough)
+	g.AddNode("a_b", passThrough)
+	g.AddEdge("a-b", "a_b")
+	g.AddEdge("a_b", agora.END)
+
+	out := g.Mermaid()
+	for _, want := range []string{`n_a_2db["a-b"]`, `n_a__b["a_b"]`, "n_a_2db --> n_a__b"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected Mermaid output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+// TestGraph_Mermaid_Labels verifies labels are written without Go escapes,
+// quotes as the entity Mermaid understands.
+func TestGraph_Mermaid_Labels(t *testing.T) {
+	g := agora.NewGraph()
+	g.SetEntry(`say "hi"`)
+	g.AddNode(`say "hi"`, passThrough)
+	g.AddNode(`C:\tmp`, passThrough)
+	g.AddEdge(`say "hi"`, `C:\tmp`)
+	g.AddEdge(`C:\tmp`, agora.END)
+
+	out := g.Mermaid()
+	for _, want := range []string{`["say #quot;hi#quot;"]`, `["C:\tmp"]`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected Mermaid output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if dot := g.DOT(); !strings.Contains(dot, `label="say \"hi\""`) {
+		t.Errorf("expected DOT to keep quoted labels, got:\n%s", dot)
+	}
+}
+
+func TestGraph_Render_SelfReference(t *testing.T) {
+	g := agora.NewGraph()
+	g.SetEntry("loop")
+	g.AddNode("loop", passThrough, agora.WithSubGraph(g))
+	g.AddEdge("loop", agora.END)
+
+	// A graph that contains itself must not recurse forever.
+	if out := g.Mermaid(); !strings.Contains(out, "n_loop --> n_____end____") {
+		t.Errorf("expected self-referencing node to render as a plain node, got:\n%s", out)
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+func routeCall(route, rationale string) agora.ChatMessage {
+	call := agora.ToolCall{ID: "call_1", Type: "function"}
+	call.Function.Name = "choose_route"
+	call.Function.Arguments = map[string]interface{}{"route": route, "rationale": rationale}
+	return agora.ChatMessage{Role: "assistant", ToolCalls: []agora.ToolCall{call}}
+}
+
+func newRouterGraph(reply agora.ChatMessage) (*agora.Graph, *agora.ModelRequest) {
+	var seen agora.ModelRequest
+	mockLLM := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			seen = request
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+		},
+	}
+
+	mark := func(name string) agora.NodeFunc {
+		return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+			s.Set("visited", name)
+			return agora.NodeResult{State: s, IsDone: true}, nil
+		}
+	}
+
+	g := agora.NewGraph()
+	g.SetEntry("router")
+	g.AddNode("router", nodes.RouterNode(mockLLM, nodes.RouterConfig{
+		Instructions: "Pick a department.",
+		Routes: []nodes.Route{
+			{Name: "billing", Description: "Invoices and payments"},
+			{Name: "support", Description: "Everything else"},
+		},
+		Default: "support",
+	}))
+	g.AddNode("billing", mark("billing"))
+	g.AddNode("support", mark("support"))
+	return g, &seen
+}
+
+func TestRouterNode_ChoosesRoute(t *testing.T) {
+	g, seen := newRouterGraph(routeCall("billing", "mentions an invoice"))
+
+	finalState, err := g.Execute(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := finalState.Get("visited"); got != "billing" {
+		t.Errorf("expected billing branch, got %v", got)
+	}
+	if got := finalState.Get("route_rationale"); got != "mentions an invoice" {
+		t.Errorf("expected rationale to be recorded, got %v", got)
+	}
+	if seen.ToolChoice != "required" || len(seen.Tools) != 1 {
+		t.Errorf("expected a forced choose_route tool, got %q with %d tools", seen.ToolChoice, len(seen.Tools))
+	}
+	if len(finalState.(*agora.ConversationState).History) != 0 {
+		t.Error("expected router not to write to history")
+	}
+}
+
+func TestRouterNode_FallsBackOnInvalidRoute(t *testing.T) {
+	g, _ := newRouterGraph(routeCall("sales", "not a declared route"))
+
+	finalState, err := g.Execute(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := finalState.Get("route"); got != "support" {
+		t.Errorf("expected default route support, got %v", got)
+	}
+	if got := finalState.Get("visited"); got != "support" {
+		t.Errorf("expected support branch, got %v", got)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/llm"
+)
+
+// sseServer serves the given data payloads as a Chat Completions event stream.
+func sseServer(t *testing.T, events ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			fmt.Fprintf(w, "data: %s\n\n", e)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestOpenAICompatibleLLM_Stream verifies SSE parsing and response assembly,
+// including tool call arguments split across chunks.
+func TestOpenAICompatibleLLM_Stream(t *testing.T) {
+	server := sseServer(t,
+		`{"id":"c1","model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"id":"c1","model":"m","choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		`{"id":"c1","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"search","arguments":"{\"q\":"}}]}}]}`,
+		`{"id":"c1","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"go\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":3,"completion_tokens":4,"total_tokens":7}}`,
+	)
+
+	model := llm.NewOpenAICompatibleLLM(server.URL, "m", "token")
+	var deltas []string
+	resp, err := model.Stream(context.Background(), agora.ModelRequest{}, func(chunk agora.ModelResponse) error {
+		deltas = append(deltas, chunk.Choices[0].Delta.Content)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	if strings.Join(deltas, "") != "Hello" || len(deltas) != 4 {
+		t.Errorf("unexpected deltas %q", deltas)
+	}
+	msg := resp.Choices[0].Message
+	if msg.Role != "assistant" || msg.Content != "Hello" || resp.Choices[0].FinishReason != "tool_calls" {
+		t.Errorf("unexpected assembled message %+v", resp.Choices[0])
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "call_1" || msg.ToolCalls[0].Function.Arguments["q"] != "go" {
+		t.Errorf("unexpected tool calls %+v", msg.ToolCalls)
+	}
+	if resp.Usage.TotalTokens != 7 {
+		t.Errorf("expected usage from the final chunk, got %+v", resp.Usage)
+	}
+}
+
+// TestOpenAICompatibleLLM_StreamStatusError verifies non-200 answers surface
+// as a retryable StatusError.
+func TestOpenAICompatibleLLM_StreamStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	model := llm.NewOpenAICompatibleLLM(server.URL, "m", "token")
+	_, err := model.Stream(context.Background(), agora.ModelRequest{}, func(agora.ModelResponse) error { return nil })
+
+	var statusErr *agora.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests || !agora.IsRetryable(err) {
+		t.Errorf("expected a retryable 429 StatusError, got %v", err)
+	}
+}
+
+// TestStream_FallsBackToInvoke verifies llm.Stream with a non-streaming LLM.
+func TestStream_FallsBackToInvoke(t *testing.T) {
+	mockLLM := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: agora.ChatMessage{Role: "assistant", Content: "whole"}}}}, nil
+		},
+	}
+
+	var chunks int
+	resp, err := llm.Stream(context.Background(), mockLLM, agora.ModelRequest{}, func(chunk agora.ModelResponse) error {
+		chunks++
+		if chunk.Choices[0].Delta.Content != "whole" {
+			t.Errorf("unexpected chunk %+v", chunk)
+		}
+		return nil
+	})
+	if err != nil || chunks != 1 || resp.Choices[0].Message.Content != "whole" {
+		t.Errorf("expected one chunk and the full response, got %d chunks, %+v, %v", chunks, resp, err)
+	}
+}
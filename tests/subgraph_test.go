@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+// TestSubGraph_Mapping verifies that a child graph can run on a fresh state of
+// a different type and only hands back the mapped outputs.
+func TestSubGraph_Mapping(t *testing.T) {
+	child := agora.NewGraph()
+	child.SetEntry("work")
+	child.AddNode("work", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		s.Set("answer", "topic was "+s.Get("topic").(string))
+		s.Set("scratch", "private")
+		if err := s.AppendTurn(agora.ChatMessage{Role: "assistant", Content: "child turn"}); err != nil {
+			return agora.NodeResult{State: s}, err
+		}
+		return agora.NodeResult{State: s, IsDone: true}, nil
+	})
+
+	parent := agora.NewGraph()
+	parent.SetEntry("research")
+	parent.AddNode("research", nodes.SubGraphNode(child,
+		nodes.WithChildState(func(parent agora.State) (agora.State, error) {
+			return &agora.ConversationState{BaseState: agora.NewBaseState(), Input: "child input"}, nil
+		}),
+		nodes.WithInputs(map[string]string{"query": "topic"}),
+		nodes.WithOutputs(map[string]string{"answer": "research_result"}),
+	))
+
+	state := newTestState()
+	state.Set("query", "go")
+
+	finalState, err := parent.Execute(context.Background(), state)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := finalState.Get("research_result"); got != "topic was go" {
+		t.Errorf("expected mapped output, got %v", got)
+	}
+	if finalState.Get("scratch") != nil {
+		t.Error("expected unmapped child key to stay in the child")
+	}
+	if len(finalState.(*agora.ConversationState).History) != 0 {
+		t.Error("expected child history to stay isolated from the parent")
+	}
+}
+
+// TestSubGraph_NamespacedError verifies that child failures carry the full node path.
+func TestSubGraph_NamespacedError(t *testing.T) {
+	boom := errors.New("boom")
+
+	child := agora.NewGraph()
+	child.SetEntry("search")
+	child.AddNode("search", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		if got := agora.NodePath(ctx); got != "research/search" {
+			t.Errorf("expected node path research/search, got %s", got)
+		}
+		return agora.NodeResult{State: s}, boom
+	})
+
+	parent := agora.NewGraph()
+	parent.SetEntry("research")
+	parent.AddNode("research", nodes.SubGraphNode(child, nodes.WithIsolatedHistory()))
+
+	_, err := parent.Execute(context.Background(), newTestState())
+
+	var nodeErr *agora.NodeError
+	if !errors.As(err, &nodeErr) {
+		t.Fatalf("expected NodeError, got %v", err)
+	}
+	if nodeErr.Path != "research/search" {
+		t.Errorf("expected path research/search, got %s", nodeErr.Path)
+	}
+	if !errors.Is(err, boom) {
+		t.Errorf("expected wrapped boom error, got %v", err)
+	}
+}
+
+// TestSubGraph_InheritedMaxSteps verifies that an inheriting child cannot loop
+// beyond the parent's remaining step budget.
+func TestSubGraph_InheritedMaxSteps(t *testing.T) {
+	loops := 0
+	child := agora.NewGraph()
+	child.MaxSteps = 100
+	child.SetEntry("loop")
+	child.AddNode("loop", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		loops++
+		return agora.NodeResult{State: s, NextNode: "loop"}, nil
+	})
+
+	parent := agora.NewGraph()
+	parent.MaxSteps = 5
+	parent.SetEntry("child")
+	parent.AddNode("child", nodes.SubGraphNode(child, nodes.WithInheritedMaxSteps()))
+
+	_, err := parent.Execute(context.Background(), newTestState())
+	if !errors.Is(err, agora.ErrMaxStepsExceeded) {
+		t.Fatalf("expected ErrMaxStepsExceeded, got %v", err)
+	}
+	if loops != 4 {
+		t.Errorf("expected child to be capped at 4 steps, ran %d", loops)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+func toolCallMessage(name string) agora.ChatMessage {
+	call := agora.ToolCall{ID: "call_" + name, Type: "function"}
+	call.Function.Name = name
+	call.Function.Arguments = map[string]interface{}{}
+	return agora.ChatMessage{Role: "assistant", ToolCalls: []agora.ToolCall{call}}
+}
+
+func TestSupervisorNode_DelegatesAndReturns(t *testing.T) {
+	// The supervisor delegates to the researcher once, then answers.
+	replies := []agora.ChatMessage{
+		toolCallMessage("transfer_to_researcher"),
+		{Role: "assistant", Content: "final report"},
+	}
+	calls := 0
+	mockLLM := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			if len(request.Tools) != 2 {
+				t.Errorf("expected one transfer tool per worker, got %d", len(request.Tools))
+			}
+			reply := replies[calls]
+			calls++
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: reply}}}, nil
+		},
+	}
+
+	workerRan := map[string]bool{}
+	worker := func(name string) agora.NodeFunc {
+		return func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+			workerRan[name] = true
+			if got := s.Get("active_agent"); got != name {
+				t.Errorf("expected active agent %s, got %v", name, got)
+			}
+			return agora.NodeResult{State: s}, nil
+		}
+	}
+
+	g := agora.NewGraph()
+	g.SetEntry("team")
+	g.AddNode("team", nodes.SupervisorNode(mockLLM, nodes.SupervisorConfig{
+		Instructions: "Coordinate the team.",
+		Workers: []nodes.Worker{
+			{Name: "researcher", Description: "Finds facts", Node: worker("researcher")},
+			{Name: "writer", Description: "Writes prose", Node: worker("writer")},
+		},
+	}))
+
+	finalState, err := g.Execute(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !workerRan["researcher"] || workerRan["writer"] {
+		t.Errorf("expected only the researcher to run, got %v", workerRan)
+	}
+	if calls != 2 {
+		t.Errorf("expected control to return to the supervisor, got %d supervisor calls", calls)
+	}
+	if got := finalState.Get("output"); got != "final report" {
+		t.Errorf("expected final report output, got %v", got)
+	}
+	if got := finalState.Get("active_agent"); got != "supervisor" {
+		t.Errorf("expected supervisor to be active at the end, got %v", got)
+	}
+}
+
+func TestHandoffAgentNode_JumpsToPeer(t *testing.T) {
+	mockLLM := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			return agora.ModelResponse{Choices: []agora.Choice{{Message: toolCallMessage("transfer_to_billing")}}}, nil
+		},
+	}
+
+	g := agora.NewGraph()
+	g.SetEntry("frontdesk")
+	g.AddNode("frontdesk", nodes.HandoffAgentNode(mockLLM, "Help the user.", agora.NewToolRegistry(), []nodes.Route{
+		{Name: "billing", Description: "Handles invoices"},
+	}))
+	g.AddNode("billing", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		s.Set("visited", "billing")
+		return agora.NodeResult{State: s, IsDone: true}, nil
+	})
+
+	finalState, err := g.Execute(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := finalState.Get("visited"); got != "billing" {
+		t.Errorf("expected handoff to billing, got %v", got)
+	}
+	history := finalState.(*agora.ConversationState).History
+	if last := history[len(history)-1]; last.Role != "tool" || last.ToolCallID != "call_transfer_to_billing" {
+		t.Errorf("expected the handoff call to be answered, got %+v", last)
+	}
+}
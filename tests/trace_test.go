@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/amangsingh/agora"
+	"github.com/amangsingh/agora/nodes"
+)
+
+// TestExecuteWithTrace_Steps verifies the visited path, transition reasons
+// and LLM usage recorded for each step.
+func TestExecuteWithTrace_Steps(t *testing.T) {
+	mockLLM := &MockLLM{
+		InvokeFunc: func(ctx context.Context, request agora.ModelRequest) (agora.ModelResponse, error) {
+			return agora.ModelResponse{
+				Model:   "mock",
+				Choices: []agora.Choice{{Message: agora.ChatMessage{Role: "assistant", Content: "hi"}}},
+				Usage:   agora.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+			}, nil
+		},
+	}
+
+	g := agora.NewGraph()
+	g.SetEntry("prepare")
+	g.AddNode("prepare", passThrough)
+	g.AddNode("agent", nodes.SimpleAgentNode(mockLLM, "be brief"))
+	g.AddNode("finish", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		return agora.NodeResult{State: s, IsDone: true}, nil
+	})
+	g.AddEdge("prepare", "agent")
+	g.SetConditionalEdge("agent", func(s agora.State) string { return "finish" }, "finish")
+
+	_, trace, err := g.ExecuteWithTrace(context.Background(), newTestState())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path := trace.Path(); !reflect.DeepEqual(path, []string{"prepare", "agent", "finish"}) {
+		t.Fatalf("unexpected path %v", path)
+	}
+	reasons := []agora.TransitionReason{agora.TransitionEdge, agora.TransitionConditional, agora.TransitionDone}
+	for i, step := range trace.Steps {
+		if step.Reason != reasons[i] {
+			t.Errorf("step %d: expected reason %s, got %s", i, reasons[i], step.Reason)
+		}
+	}
+
+	agentStep := trace.Steps[1]
+	if len(agentStep.LLMCalls) != 1 || agentStep.LLMCalls[0].Model != "mock" {
+		t.Errorf("expected one recorded LLM call, got %+v", agentStep.LLMCalls)
+	}
+	if agentStep.Usage.TotalTokens != 15 || trace.TotalUsage().TotalTokens != 15 {
+		t.Errorf("expected 15 tokens, got step %d total %d", agentStep.Usage.TotalTokens, trace.TotalUsage().TotalTokens)
+	}
+}
+
+// TestExecuteWithTrace_SubGraph verifies that a sub-graph's trace is nested
+// under the step that ran it and that errors are recorded.
+func TestExecuteWithTrace_SubGraph(t *testing.T) {
+	boom := errors.New("boom")
+	child := agora.NewGraph()
+	child.SetEntry("inner")
+	child.AddNode("inner", passThrough)
+	child.AddNode("fail", func(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+		return agora.NodeResult{State: s}, boom
+	})
+	child.AddEdge("inner", "fail")
+
+	parent := agora.NewGraph()
+	parent.SetEntry("child")
+	parent.AddNode("child", nodes.SubGraphNode(child))
+
+	_, trace, err := parent.ExecuteWithTrace(context.Background(), newTestState())
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+
+	if len(trace.Steps) != 1 || trace.Steps[0].Reason != agora.TransitionError {
+		t.Fatalf("expected a single failed step, got %+v", trace.Steps)
+	}
+	subTraces := trace.Steps[0].SubTraces
+	if len(subTraces) != 1 {
+		t.Fatalf("expected one sub-trace, got %d", len(subTraces))
+	}
+	sub := subTraces[0]
+	if !reflect.DeepEqual(sub.Path(), []string{"inner", "fail"}) {
+		t.Errorf("unexpected sub-trace path %v", sub.Path())
+	}
+	if last := sub.Steps[1]; last.Path != "child/fail" || last.Error == "" {
+		t.Errorf("expected namespaced failing step with an error, got %+v", last)
+	}
+}
+
+// TestExecute_MaxStepsError verifies that exceeding MaxSteps reports the
+// recent path and the cycle the graph was stuck in.
+func TestExecute_MaxStepsError(t *testing.T) {
+	g := agora.NewGraph()
+	g.MaxSteps = 7
+	g.SetEntry("start")
+	g.AddNode("start", passThrough)
+	g.AddNode("think", passThrough)
+	g.AddNode("act", passThrough)
+	g.AddEdge("start", "think")
+	g.AddEdge("think", "act")
+	g.AddEdge("act", "think")
+
+	_, trace, err := g.ExecuteWithTrace(context.Background(), newTestState())
+	if !errors.Is(err, agora.ErrMaxStepsExceeded) {
+		t.Fatalf("expected ErrMaxStepsExceeded, got %v", err)
+	}
+
+	var maxErr *agora.MaxStepsError
+	if !errors.As(err, &maxErr) {
+		t.Fatalf("expected a *MaxStepsError, got %T", err)
+	}
+	if !reflect.DeepEqual(maxErr.Recent, []string{"start", "think", "act", "think", "act", "think", "act"}) {
+		t.Errorf("unexpected recent path %v", maxErr.Recent)
+	}
+	if !reflect.DeepEqual(maxErr.Cycle, []string{"think", "act"}) {
+		t.Errorf("expected cycle [think act], got %v", maxErr.Cycle)
+	}
+	if len(trace.Steps) != 7 {
+		t.Errorf("expected 7 traced steps, got %d", len(trace.Steps))
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/amangsingh/agora"
+)
+
+func passThrough(ctx context.Context, s agora.State) (agora.NodeResult, error) {
+	return agora.NodeResult{State: s}, nil
+}
+
+func TestGraph_Validate_Valid(t *testing.T) {
+	g := agora.NewGraph()
+	g.SetEntry("agent")
+	g.AddNode("agent", passThrough)
+	g.AddNode("tools", passThrough)
+	g.AddNode("router", passThrough, agora.WithRoutes("agent", agora.END))
+	g.SetConditionalEdge("agent", func(s agora.State) string { return "tools" }, "tools", "router")
+	g.AddEdge("tools", "agent")
+
+	if err := g.Validate(); err != nil {
+		t.Fatalf("expected valid graph, got %v", err)
+	}
+}
+
+func TestGraph_Validate_Problems(t *testing.T) {
+	g := agora.NewGraph()
+	g.SetEntry("start")
+	g.AddNode("start", passThrough)
+	g.AddNode("orphan", passThrough)
+	g.AddNode("dead_end", passThrough)
+	g.AddEdge("start", "dead_end")
+	g.AddEdge("orphan", agora.END)
+	g.SetConditionalEdge("dead_end", func(s agora.State) string { return agora.END }, "typo")
+
+	err := g.Validate()
+
+	var validationErr *agora.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		`conditional edge dead_end -> typo targets an unknown node`,
+		`node "orphan" is unreachable`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected problem %q in %q", want, msg)
+		}
+	}
+}
+
+func TestGraph_Validate_MissingEntryAndDeadEnd(t *testing.T) {
+	g := agora.NewGraph()
+	g.SetEntry("missing")
+	g.AddNode("lonely", passThrough)
+	g.AddEdge("lonely", "nowhere")
+	g.AddNode("stuck", passThrough)
+
+	msg := g.Validate().Error()
+	for _, want := range []string{
+		`entry node "missing" does not exist`,
+		`edge lonely -> nowhere targets an unknown node`,
+		`node "stuck" has no outgoing path`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected problem %q in %q", want, msg)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/amangsingh/agora/vectorstore"
+)
+
+// TestMetric_Similarity verifies cosine ignores vector length while the dot
+// product does not.
+func TestMetric_Similarity(t *testing.T) {
+	a, b := []float32{1, 0}, []float32{3, 0}
+	if got := vectorstore.Cosine.Similarity(a, b); math.Abs(got-1) > 1e-9 {
+		t.Errorf("expected cosine 1, got %v", got)
+	}
+	if got := vectorstore.DotProduct.Similarity(a, b); got != 3 {
+		t.Errorf("expected dot product 3, got %v", got)
+	}
+	if got := vectorstore.Cosine.Similarity(a, []float32{0, 0}); got != 0 {
+		t.Errorf("expected 0 for a zero vector, got %v", got)
+	}
+}
+
+// TestMemoryStore verifies upserts, filtered search, ranking and deletes.
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := vectorstore.NewMemoryStore(vectorstore.Cosine)
+
+	docs := []vectorstore.Document{
+		{ID: "north", Content: "up", Vector: []float32{0, 1}, Metadata: map[string]string{"lang": "en"}},
+		{ID: "east", Content: "right", Vector: []float32{1, 0}, Metadata: map[string]string{"lang": "en"}},
+		{ID: "north-east", Content: "diagonal", Vector: []float32{1, 1}, Metadata: map[string]string{"lang": "fr"}},
+	}
+	if err := store.Upsert(ctx, docs...); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if err := store.Upsert(ctx, vectorstore.Document{ID: "bad", Vector: []float32{1, 2, 3}}); err == nil {
+		t.Error("expected mismatched dimensions to be rejected")
+	}
+
+	// 1. Ranked by similarity to the query.
+	results, err := store.Search(ctx, []float32{0.9, 0.1}, 2, nil)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "east" || results[1].ID != "north-east" {
+		t.Errorf("unexpected ranking: %+v", results)
+	}
+
+	// 2. Filtered by metadata.
+	results, _ = store.Search(ctx, []float32{1, 1}, 0, vectorstore.Filter{"lang": "en"})
+	if len(results) != 2 || results[0].Metadata["lang"] != "en" {
+		t.Errorf("expected only english documents, got %+v", results)
+	}
+
+	// 3. Upsert replaces, delete removes.
+	store.Upsert(ctx, vectorstore.Document{ID: "east", Content: "replaced", Vector: []float32{1, 0}})
+	store.Delete(ctx, "north", "missing")
+	results, _ = store.Search(ctx, []float32{1, 0}, 0, nil)
+	if len(results) != 2 || results[0].Content != "replaced" {
+		t.Errorf("unexpected documents after upsert and delete: %+v", results)
+	}
+	if _, err := store.Search(ctx, []float32{1}, 1, nil); err == nil {
+		t.Error("expected a query with the wrong dimensions to fail")
+	}
+}
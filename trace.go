@@ -0,0 +1,216 @@
+// in agora/trace.go
+
+package agora
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recentStepsLimit is how many visited nodes a MaxStepsError keeps.
+const recentStepsLimit = 10
+
+// TransitionReason explains why execution left a step.
+type TransitionReason string
+
+const (
+	TransitionDone        TransitionReason = "done"        // The node returned IsDone.
+	TransitionNextNode    TransitionReason = "next_node"   // The node returned NextNode.
+	TransitionConditional TransitionReason = "conditional" // A conditional edge chose the next node.
+	TransitionEdge        TransitionReason = "edge"        // A static edge was followed.
+	TransitionEnd         TransitionReason = "end"         // No outgoing path, so execution stopped.
+	TransitionFallback    TransitionReason = "fallback"    // The node failed and its fallback took over.
+	TransitionError       TransitionReason = "error"       // The node failed and execution stopped.
+)
+
+// LLMCall is one model invocation reported by a node through ReportModelResponse.
+type LLMCall struct {
+	Model   string  `json:"model"`
+	Backend string  `json:"backend,omitempty"`
+	Usage   Usage   `json:"usage"`
+	Timings Timings `json:"timings"`
+}
+
+// TraceEvent is something notable a node reported through RecordEvent,
+// such as a guardrail decision, kept in the trace for audit.
+type TraceEvent struct {
+	Kind    string            `json:"kind"`
+	Message string            `json:"message,omitempty"`
+	Data    map[string]string `json:"data,omitempty"`
+	Time    time.Time         `json:"time"`
+}
+
+// TraceStep records a single node execution.
+type TraceStep struct {
+	Node     string           `json:"node"`
+	Path     string           `json:"path"`
+	Start    time.Time        `json:"start"`
+	Duration time.Duration    `json:"duration"`
+	Next     string           `json:"next,omitempty"`
+	Reason   TransitionReason `json:"reason"`
+	Usage    Usage            `json:"usage"` // Summed over LLMCalls
+	LLMCalls []LLMCall        `json:"llm_calls,omitempty"`
+	Events   []TraceEvent     `json:"events,omitempty"`
+	Error    string           `json:"error,omitempty"`
+	// SubTraces holds the traces of graphs executed inside this step, such as
+	// a SubGraphNode (or one per item for MapGraphNode).
+	SubTraces []*Trace `json:"sub_traces,omitempty"`
+}
+
+// Trace is the ordered record of a graph execution.
+type Trace struct {
+	Steps []TraceStep `json:"steps"`
+}
+
+// Path returns the names of the visited nodes in order.
+func (t *Trace) Path() []string {
+	path := make([]string, len(t.Steps))
+	for i, step := range t.Steps {
+		path[i] = step.Node
+	}
+	return path
+}
+
+// TotalUsage sums the LLM usage of every step, including nested sub-traces.
+func (t *Trace) TotalUsage() Usage {
+	var total Usage
+	for _, step := range t.Steps {
+		total = addUsage(total, step.Usage)
+		for _, sub := range step.SubTraces {
+			total = addUsage(total, sub.TotalUsage())
+		}
+	}
+	return total
+}
+
+// MaxStepsError is returned when execution exceeds MaxSteps. It matches
+// ErrMaxStepsExceeded with errors.Is.
+type MaxStepsError struct {
+	MaxSteps int
+	// Recent is the last few nodes visited, oldest first.
+	Recent []string
+	// Cycle is the repeating sequence of nodes at the end of the run, if any.
+	Cycle []string
+}
+
+func (e *MaxStepsError) Error() string {
+	msg := fmt.Sprintf("%s (%d)", ErrMaxStepsExceeded, e.MaxSteps)
+	if len(e.Cycle) > 0 {
+		return msg + ": stuck in cycle " + strings.Join(append(e.Cycle, e.Cycle[0]), " -> ")
+	}
+	if len(e.Recent) > 0 {
+		return msg + ": last visited " + strings.Join(e.Recent, " -> ")
+	}
+	return msg
+}
+
+func (e *MaxStepsError) Is(target error) bool {
+	return target == ErrMaxStepsExceeded
+}
+
+// newMaxStepsError builds the error from the full list of visited nodes.
+func newMaxStepsError(maxSteps int, visited []string) *MaxStepsError {
+	recent := visited[max(len(visited)-recentStepsLimit, 0):]
+	return &MaxStepsError{
+		MaxSteps: maxSteps,
+		Recent:   append([]string(nil), recent...),
+		Cycle:    detectCycle(visited),
+	}
+}
+
+// detectCycle returns the shortest sequence that repeats at least twice at
+// the end of visited.
+func detectCycle(visited []string) []string {
+	n := len(visited)
+	for period := 1; period <= n/2; period++ {
+		repeats := true
+		for i := 0; i < period; i++ {
+			if visited[n-1-i] != visited[n-1-i-period] {
+				repeats = false
+				break
+			}
+		}
+		if repeats {
+			return append([]string(nil), visited[n-period:]...)
+		}
+	}
+	return nil
+}
+
+// ReportModelResponse records an LLM call against the node executing in ctx,
+// so it shows up in the step's usage and is charged to the graph's Budget. It
+// is a no-op outside of Graph.Execute.
+func ReportModelResponse(ctx context.Context, resp ModelResponse) {
+	if rec := recorderFrom(ctx); rec != nil {
+		rec.addCall(LLMCall{Model: resp.Model, Backend: resp.Backend, Usage: resp.Usage, Timings: resp.Timings})
+	}
+	budgetFrom(ctx).charge(resp)
+}
+
+// RecordEvent adds an event to the trace step of the node executing in ctx.
+// The time is filled in if unset. It is a no-op outside of Graph.Execute.
+func RecordEvent(ctx context.Context, event TraceEvent) {
+	if rec := recorderFrom(ctx); rec != nil {
+		if event.Time.IsZero() {
+			event.Time = time.Now()
+		}
+		rec.addEvent(event)
+	}
+}
+
+type stepRecorderKey struct{}
+
+// stepRecorder collects what happens during one step. Nodes such as
+// ParallelNode report from several goroutines, so it is mutex-protected.
+type stepRecorder struct {
+	mu        sync.Mutex
+	calls     []LLMCall
+	events    []TraceEvent
+	subTraces []*Trace
+}
+
+func recorderFrom(ctx context.Context) *stepRecorder {
+	rec, _ := ctx.Value(stepRecorderKey{}).(*stepRecorder)
+	return rec
+}
+
+func (r *stepRecorder) addCall(call LLMCall) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *stepRecorder) addEvent(event TraceEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *stepRecorder) addSubTrace(t *Trace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subTraces = append(r.subTraces, t)
+}
+
+// finish copies the recorded calls, events and sub-traces into step.
+func (r *stepRecorder) finish(step *TraceStep) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	step.LLMCalls = r.calls
+	step.Events = r.events
+	step.SubTraces = r.subTraces
+	for _, call := range r.calls {
+		step.Usage = addUsage(step.Usage, call.Usage)
+	}
+}
+
+func addUsage(a, b Usage) Usage {
+	return Usage{
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+	}
+}
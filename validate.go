@@ -0,0 +1,154 @@
+// in agora/validate.go
+
+package agora
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationError lists every problem Validate found in a graph.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid graph: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the graph's topology before it is executed. It reports a
+// missing or unknown entry, edges and declared destinations that point at
+// unknown nodes, nodes with no outgoing path that are not marked terminal
+// (with an edge to END), and nodes that cannot be reached from the entry.
+//
+// Dynamic transitions are only visible when declared: conditional edges via
+// the targets of SetConditionalEdge, NextNode jumps via WithRoutes. While any
+// reachable conditional edge is undeclared, the unreachable-node check is
+// skipped because the destinations cannot be known.
+func (g *Graph) Validate() error {
+	var problems []string
+	exists := func(name string) bool {
+		_, ok := g.Nodes[name]
+		return ok || name == END
+	}
+
+	// 1. Entry and circuit breaker.
+	switch {
+	case g.Entry == "":
+		problems = append(problems, "entry node is not set")
+	case !exists(g.Entry):
+		problems = append(problems, fmt.Sprintf("entry node %q does not exist", g.Entry))
+	}
+	if g.MaxSteps <= 0 {
+		problems = append(problems, fmt.Sprintf("max steps must be positive, got %d", g.MaxSteps))
+	}
+
+	// 2. Every declared transition must point at a known node.
+	for _, source := range sortedKeys(g.Edges) {
+		if _, ok := g.Nodes[source]; !ok {
+			problems = append(problems, fmt.Sprintf("edge source %q does not exist", source))
+		}
+		if target := g.Edges[source]; !exists(target) {
+			problems = append(problems, fmt.Sprintf("edge %s -> %s targets an unknown node", source, target))
+		}
+	}
+	for _, source := range sortedKeys(g.ConditionalEdges) {
+		if _, ok := g.Nodes[source]; !ok {
+			problems = append(problems, fmt.Sprintf("conditional edge source %q does not exist", source))
+		}
+	}
+	for _, source := range sortedKeys(g.ConditionalTargets) {
+		for _, target := range g.ConditionalTargets[source] {
+			if !exists(target) {
+				problems = append(problems, fmt.Sprintf("conditional edge %s -> %s targets an unknown node", source, target))
+			}
+		}
+	}
+	for _, name := range sortedKeys(g.NodeConfigs) {
+		cfg := g.NodeConfigs[name]
+		for _, target := range cfg.Routes {
+			if !exists(target) {
+				problems = append(problems, fmt.Sprintf("route %s -> %s targets an unknown node", name, target))
+			}
+		}
+		if cfg.Fallback != "" && !exists(cfg.Fallback) {
+			problems = append(problems, fmt.Sprintf("fallback %s -> %s targets an unknown node", name, cfg.Fallback))
+		}
+	}
+
+	// 3. Every node needs a way out, or must be explicitly terminal.
+	for _, name := range sortedKeys(g.Nodes) {
+		_, hasEdge := g.Edges[name]
+		_, hasConditional := g.ConditionalEdges[name]
+		if !hasEdge && !hasConditional && len(g.NodeConfigs[name].Routes) == 0 {
+			problems = append(problems, fmt.Sprintf("node %q has no outgoing path; add an edge to END if it is terminal", name))
+		}
+	}
+
+	// 4. Every node must be reachable from the entry.
+	if exists(g.Entry) {
+		reachable, complete := g.reachableFrom(g.Entry)
+		if complete {
+			for _, name := range sortedKeys(g.Nodes) {
+				if !reachable[name] {
+					problems = append(problems, fmt.Sprintf("node %q is unreachable from entry %q", name, g.Entry))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Successors returns every declared destination of a node: its static edge,
+// its declared conditional targets, its declared routes and its fallback.
+func (g *Graph) Successors(name string) []string {
+	var next []string
+	if target, ok := g.Edges[name]; ok {
+		next = append(next, target)
+	}
+	next = append(next, g.ConditionalTargets[name]...)
+	cfg := g.NodeConfigs[name]
+	next = append(next, cfg.Routes...)
+	if cfg.Fallback != "" {
+		next = append(next, cfg.Fallback)
+	}
+	return next
+}
+
+// reachableFrom walks the declared transitions from start. complete is false
+// when a reachable conditional edge has no declared targets.
+func (g *Graph) reachableFrom(start string) (map[string]bool, bool) {
+	reachable := map[string]bool{start: true}
+	complete := true
+	queue := []string{start}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+
+		if _, ok := g.ConditionalEdges[name]; ok && len(g.ConditionalTargets[name]) == 0 {
+			complete = false
+		}
+		for _, next := range g.Successors(name) {
+			if !reachable[next] {
+				reachable[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return reachable, complete
+}
+
+// sortedKeys returns the keys of a string-keyed map in a stable order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
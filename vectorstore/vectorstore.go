@@ -0,0 +1,178 @@
+// Package vectorstore stores embedded documents and finds the ones most
+// similar to a query vector.
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Document is a piece of text with its embedding and metadata.
+type Document struct {
+	ID       string            `json:"id"`
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Vector   []float32         `json:"vector"`
+}
+
+// Result is a document matched by a search, with its similarity to the query.
+type Result struct {
+	Document
+	Score float64 `json:"score"`
+}
+
+// Filter restricts a search to documents whose metadata has every given
+// key set to the given value. A nil filter matches everything.
+type Filter map[string]string
+
+// Match reports whether metadata satisfies the filter.
+func (f Filter) Match(metadata map[string]string) bool {
+	for k, v := range f {
+		if got, ok := metadata[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// VectorStore persists documents and searches them by similarity.
+// pkg/storage provides a SQLite implementation; MemoryStore is an in-process one.
+type VectorStore interface {
+	// Upsert adds documents, replacing any with the same ID.
+	Upsert(ctx context.Context, docs ...Document) error
+	// Delete removes documents by ID. Unknown IDs are ignored.
+	Delete(ctx context.Context, ids ...string) error
+	// Search returns the k documents most similar to query that match filter,
+	// best first. A k of zero or less returns every match.
+	Search(ctx context.Context, query []float32, k int, filter Filter) ([]Result, error)
+}
+
+// Metric is how similarity between two vectors is measured.
+type Metric int
+
+const (
+	// Cosine compares direction only, so vector length does not matter.
+	Cosine Metric = iota
+	// DotProduct also rewards longer vectors. For normalized embeddings it
+	// ranks like Cosine and is cheaper.
+	DotProduct
+)
+
+// Similarity scores two vectors of the same length; higher is more similar.
+func (m Metric) Similarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if m == DotProduct {
+		return dot
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// Rank sorts results best first, breaking ties by ID, and keeps the top k.
+// A k of zero or less keeps them all.
+func Rank(results []Result, k int) []Result {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// CheckDimensions returns an error unless every vector has n dimensions.
+// An n of zero accepts the first vector's length.
+func CheckDimensions(n int, vectors ...[]float32) (int, error) {
+	for _, v := range vectors {
+		if len(v) == 0 {
+			return n, fmt.Errorf("vector is empty")
+		}
+		if n == 0 {
+			n = len(v)
+		}
+		if len(v) != n {
+			return n, fmt.Errorf("vector has %d dimensions, expected %d", len(v), n)
+		}
+	}
+	return n, nil
+}
+
+// MemoryStore is a brute-force VectorStore held in memory. Every search
+// scores every document, which is fine for tests and small corpora.
+type MemoryStore struct {
+	metric Metric
+
+	mu   sync.RWMutex
+	docs map[string]Document
+	dims int
+}
+
+// NewMemoryStore creates an empty store using the given metric.
+func NewMemoryStore(metric Metric) *MemoryStore {
+	return &MemoryStore{metric: metric, docs: make(map[string]Document)}
+}
+
+// Upsert implements VectorStore. All vectors must have the same dimensions.
+func (m *MemoryStore) Upsert(ctx context.Context, docs ...Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dims := m.dims
+	if len(m.docs) == 0 {
+		dims = 0
+	}
+	for _, doc := range docs {
+		var err error
+		if dims, err = CheckDimensions(dims, doc.Vector); err != nil {
+			return fmt.Errorf("document %s: %w", doc.ID, err)
+		}
+	}
+	m.dims = dims
+	for _, doc := range docs {
+		m.docs[doc.ID] = doc
+	}
+	return nil
+}
+
+// Delete implements VectorStore.
+func (m *MemoryStore) Delete(ctx context.Context, ids ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		delete(m.docs, id)
+	}
+	return nil
+}
+
+// Search implements VectorStore.
+func (m *MemoryStore) Search(ctx context.Context, query []float32, k int, filter Filter) ([]Result, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.docs) == 0 {
+		return nil, nil
+	}
+	if _, err := CheckDimensions(m.dims, query); err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	var results []Result
+	for _, doc := range m.docs {
+		if filter.Match(doc.Metadata) {
+			results = append(results, Result{Document: doc, Score: m.metric.Similarity(query, doc.Vector)})
+		}
+	}
+	return Rank(results, k), nil
+}